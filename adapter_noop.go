@@ -26,6 +26,13 @@ func (l *NoopLogger) Error(_ string, _ ...xfield.Field) {}
 // Fatal is a no-op implementation.
 func (l *NoopLogger) Fatal(_ string, _ ...xfield.Field) {}
 
+// DPanic panics with the given message if the global development switch is on.
+func (l *NoopLogger) DPanic(msg string, _ ...xfield.Field) {
+	if IsDevelopment() {
+		panic(msg)
+	}
+}
+
 // Panic panics with the given message.
 func (l *NoopLogger) Panic(msg string, _ ...xfield.Field) { panic(msg) }
 
@@ -26,12 +26,26 @@ func WithPanicFunc(fn func(string)) SlogOption {
 	}
 }
 
+// WithDevelopment makes DPanic panic after logging when enabled, whatever the global
+// development switch. Without it, the adapter follows ReplaceDevelopment.
+func WithDevelopment(enabled bool) SlogOption {
+	return func(s *SlogAdapter) {
+		s.development = &enabled
+	}
+}
+
+// SlogLevelDPanic is the slog level of the entries logged by SlogAdapter.DPanic,
+// between slog.LevelError and the level of a Panic. slog handlers print it as "ERROR+2".
+const SlogLevelDPanic = slog.LevelError + 2
+
 // SlogAdapter adapts a slog.Logger to the xlog.Logger interface.
 type SlogAdapter struct {
-	logger    *slog.Logger
-	ctx       context.Context // context for slog operations
-	exitFunc  func()          // function to call instead of os.Exit (for testing)
-	panicFunc func(string)    // function to call instead of panic (for testing)
+	logger      *slog.Logger
+	name        string          // logger name built by Named
+	ctx         context.Context // context for slog operations
+	exitFunc    func()          // function to call instead of os.Exit (for testing)
+	panicFunc   func(string)    // function to call instead of panic (for testing)
+	development *bool           // development mode set by WithDevelopment, nil to follow the global switch
 }
 
 // NewSlogAdapter creates a new SlogAdapter wrapping the given slog.Logger.
@@ -93,6 +107,14 @@ func (s *SlogAdapter) Fatal(msg string, fields ...xfield.Field) {
 	s.exitFunc()
 }
 
+// DPanic logs a message at SlogLevelDPanic and panics in development, see WithDevelopment.
+func (s *SlogAdapter) DPanic(msg string, fields ...xfield.Field) {
+	s.logger.Log(s.ctx, SlogLevelDPanic, msg, fieldsToSlogAttrs(fields)...)
+	if s.isDevelopment() {
+		s.panicFunc(msg)
+	}
+}
+
 // Panic logs a panic-level message and panics.
 // Note: slog doesn't have a Panic level, so we log as Error with a special marker and panic.
 func (s *SlogAdapter) Panic(msg string, fields ...xfield.Field) {
@@ -110,12 +132,12 @@ func (s *SlogAdapter) With(fields ...xfield.Field) Logger {
 // Named creates a child logger with the given name.
 // In slog, this is implemented by adding a "logger" field with the name.
 func (s *SlogAdapter) Named(name string) Logger {
-	return &SlogAdapter{
-		logger:    s.logger.With(slog.String("logger", name)),
-		ctx:       s.ctx,
-		exitFunc:  s.exitFunc,
-		panicFunc: s.panicFunc,
+	adapter := s.clone(s.logger.With(slog.String("logger", name)))
+	adapter.name = joinLoggerName(s.name, name)
+	if h, ok := adapter.logger.Handler().(*levelControllerHandler); ok {
+		adapter.logger = slog.New(&levelControllerHandler{Handler: h.Handler, controller: h.controller, name: adapter.name})
 	}
+	return adapter
 }
 
 // Sync flushes any buffered log entries.
@@ -124,6 +146,11 @@ func (s *SlogAdapter) Sync() error {
 	return nil
 }
 
+// Enabled reports whether the slog handler writes records at the given level.
+func (s *SlogAdapter) Enabled(level Level) bool {
+	return s.logger.Enabled(s.ctx, level.slogLevel())
+}
+
 // Unwrap returns the underlying slog.Logger.
 // This is useful for cases where you need direct access to slog-specific features.
 func (s *SlogAdapter) Unwrap() *slog.Logger {
@@ -132,11 +159,130 @@ func (s *SlogAdapter) Unwrap() *slog.Logger {
 
 // WithContext returns a new adapter with the given context.
 func (s *SlogAdapter) WithContext(ctx context.Context, fields ...xfield.Field) Logger {
-	return &SlogAdapter{
-		logger:    s.logger.With(fieldsToSlogAttrs(fields)...),
-		ctx:       ctx,
-		exitFunc:  s.exitFunc,
-		panicFunc: s.panicFunc,
+	adapter := s.clone(s.logger.With(fieldsToSlogAttrs(fields)...))
+	adapter.ctx = ctx
+	return adapter
+}
+
+// WithLevelOverride returns a child logger with the given minimum level.
+// The level takes precedence over the level of the underlying slog.Handler,
+// so it can be used to enable records the handler would otherwise discard.
+func (s *SlogAdapter) WithLevelOverride(level Level) Logger {
+	return s.clone(slog.New(&levelOverrideHandler{
+		Handler: unwrapLevelHandler(s.logger.Handler()),
+		level:   level.slogLevel(),
+	}))
+}
+
+// WithLevelController returns a child logger whose levels are decided by the controller,
+// matching its overrides against the name built by Named.
+// The controller takes precedence over the level of the underlying slog.Handler.
+func (s *SlogAdapter) WithLevelController(controller *LevelController) Logger {
+	return s.clone(slog.New(&levelControllerHandler{
+		Handler:    unwrapLevelHandler(s.logger.Handler()),
+		controller: controller,
+		name:       s.name,
+	}))
+}
+
+func (s *SlogAdapter) loggerName() string {
+	return s.name
+}
+
+func (s *SlogAdapter) now() time.Time {
+	return time.Now()
+}
+
+func (s *SlogAdapter) logAt(t time.Time, level Level, msg string, fields []xfield.Field) {
+	if !s.logger.Enabled(s.ctx, level.slogLevel()) {
+		return
+	}
+	r := slog.NewRecord(t, level.slogLevel(), msg, 0)
+	r.Add(fieldsToSlogAttrs(fields)...)
+	_ = s.logger.Handler().Handle(s.ctx, r)
+}
+
+func (s *SlogAdapter) isDevelopment() bool {
+	if s.development != nil {
+		return *s.development
+	}
+	return IsDevelopment()
+}
+
+// clone returns a copy of the adapter using the given slog.Logger.
+func (s *SlogAdapter) clone(logger *slog.Logger) *SlogAdapter {
+	adapter := *s
+	adapter.logger = logger
+	return &adapter
+}
+
+// levelOverrideHandler is a slog.Handler that replaces the minimum level of the wrapped handler.
+// slog handlers don't check the level in Handle, so records below the level of the
+// wrapped handler are still written.
+type levelOverrideHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+// Enabled reports whether the level is enabled by the override.
+func (h *levelOverrideHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// WithAttrs returns a new handler with the given attributes, keeping the override.
+func (h *levelOverrideHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelOverrideHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+// WithGroup returns a new handler with the given group, keeping the override.
+func (h *levelOverrideHandler) WithGroup(name string) slog.Handler {
+	return &levelOverrideHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// levelControllerHandler is a slog.Handler whose levels are decided by a LevelController
+// for the logger name it was created with.
+type levelControllerHandler struct {
+	slog.Handler
+	controller *LevelController
+	name       string
+}
+
+// Enabled reports whether the controller enables the level for the handler's logger name.
+func (h *levelControllerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.controller.Enabled(h.name, levelFromSlog(level))
+}
+
+// WithAttrs returns a new handler with the given attributes, keeping the controller.
+func (h *levelControllerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelControllerHandler{Handler: h.Handler.WithAttrs(attrs), controller: h.controller, name: h.name}
+}
+
+// WithGroup returns a new handler with the given group, keeping the controller.
+func (h *levelControllerHandler) WithGroup(name string) slog.Handler {
+	return &levelControllerHandler{Handler: h.Handler.WithGroup(name), controller: h.controller, name: h.name}
+}
+
+// unwrapLevelHandler strips a level override or controller handler, so they don't stack.
+func unwrapLevelHandler(handler slog.Handler) slog.Handler {
+	switch h := handler.(type) {
+	case *levelOverrideHandler:
+		return h.Handler
+	case *levelControllerHandler:
+		return h.Handler
+	default:
+		return handler
+	}
+}
+
+// joinLoggerName appends a name to the parent logger name the same way zap does.
+func joinLoggerName(parent, name string) string {
+	switch {
+	case parent == "":
+		return name
+	case name == "":
+		return parent
+	default:
+		return parent + "." + name
 	}
 }
 
@@ -198,6 +344,19 @@ func fieldToSlogAttr(f xfield.Field) slog.Attr {
 	case xfield.ArrayType, xfield.BinaryType, xfield.ObjectType, xfield.AnyType:
 		return slog.Any(f.Key, f.Interface)
 
+	case xfield.GroupType:
+		fields, ok := f.Interface.([]xfield.Field)
+		if !ok {
+			return slog.Any(f.Key, f.Interface)
+		}
+		attrs := make([]slog.Attr, 0, len(fields))
+		for _, nested := range fields {
+			if attr := fieldToSlogAttr(nested); attr.Key != "" {
+				attrs = append(attrs, attr)
+			}
+		}
+		return slog.Attr{Key: f.Key, Value: slog.GroupValue(attrs...)}
+
 	default:
 		// Unknown type: use Any as fallback
 		return slog.Any(f.Key, f.Interface)
@@ -1,6 +1,7 @@
 package xlog
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"log/slog"
@@ -245,6 +246,37 @@ func TestFieldsToSlogAttrs(t *testing.T) {
 	})
 }
 
+func TestSlogAdapterDPanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	var panicked []string
+	newAdapter := func(options ...SlogOption) DPanicLogger {
+		options = append(options, WithPanicFunc(func(msg string) { panicked = append(panicked, msg) }))
+		return NewSlogAdapter(logger, options...).(DPanicLogger)
+	}
+
+	newAdapter().DPanic("global off")
+	newAdapter(WithDevelopment(true)).DPanic("option on")
+	t.Cleanup(ReplaceDevelopment(true))
+	newAdapter().DPanic("global on")
+	newAdapter(WithDevelopment(false)).DPanic("option off")
+
+	assert.Equal(t, []string{"option on", "global on"}, panicked)
+	assert.Equal(t, `level=ERROR+2 msg="global off"
+level=ERROR+2 msg="option on"
+level=ERROR+2 msg="global on"
+level=ERROR+2 msg="option off"
+`, buf.String())
+}
+
 func initSlogAdapter(t *testing.T) (Logger, logObserver) {
 	t.Helper()
 
@@ -366,6 +398,8 @@ func mapSlogLevel(level slog.Level) int {
 		return warnLevel
 	case slog.LevelError:
 		return errorLevel
+	case SlogLevelDPanic:
+		return dpanicLevel
 	default:
 		return infoLevel
 	}
@@ -373,8 +407,16 @@ func mapSlogLevel(level slog.Level) int {
 
 func addAttrToMap(m map[string]interface{}, attr slog.Attr) {
 	key := attr.Key
-	val := attr.Value.Any()
+	if attr.Value.Kind() == slog.KindGroup {
+		group := make(map[string]interface{})
+		for _, nested := range attr.Value.Group() {
+			addAttrToMap(group, nested)
+		}
+		m[key] = group
+		return
+	}
 
+	val := attr.Value.Any()
 	if err, ok := val.(error); ok {
 		m[key] = err.Error()
 	} else {
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/ruko1202/xlog/xfield"
 )
@@ -46,6 +47,11 @@ func (z *ZapAdapter) Fatal(msg string, fields ...xfield.Field) {
 	z.logger.Fatal(msg, fieldsToZapFields(fields)...)
 }
 
+// DPanic logs a dpanic-level message and panics if the zap logger is in development.
+func (z *ZapAdapter) DPanic(msg string, fields ...xfield.Field) {
+	z.logger.DPanic(msg, fieldsToZapFields(fields)...)
+}
+
 // Panic logs a panic-level message and panics.
 func (z *ZapAdapter) Panic(msg string, fields ...xfield.Field) {
 	z.logger.Panic(msg, fieldsToZapFields(fields)...)
@@ -70,12 +76,128 @@ func (z *ZapAdapter) Sync() error {
 	return z.logger.Sync()
 }
 
+// WithLevelOverride returns a child logger with the given minimum level.
+// The level takes precedence over the level of the underlying zap core,
+// so it can be used to enable entries the core would otherwise discard.
+func (z *ZapAdapter) WithLevelOverride(level Level) Logger {
+	return &ZapAdapter{
+		logger: z.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelOverrideCore(core, level.zapLevel())
+		})),
+	}
+}
+
+// WithLevelController returns a child logger whose levels are decided by the controller,
+// matching its overrides against the zap logger name.
+// The controller takes precedence over the level of the underlying zap core.
+func (z *ZapAdapter) WithLevelController(controller *LevelController) Logger {
+	return &ZapAdapter{
+		logger: z.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &levelControllerCore{Core: unwrapLevelCore(core), controller: controller}
+		})),
+	}
+}
+
+// Enabled reports whether the zap logger writes entries at the given level.
+func (z *ZapAdapter) Enabled(level Level) bool {
+	return z.logger.Check(level.zapLevel(), "") != nil
+}
+
+func (z *ZapAdapter) loggerName() string {
+	return z.logger.Name()
+}
+
+func (z *ZapAdapter) now() time.Time {
+	return time.Now()
+}
+
+func (z *ZapAdapter) logAt(t time.Time, level Level, msg string, fields []xfield.Field) {
+	if ce := z.logger.Check(level.zapLevel(), msg); ce != nil {
+		ce.Time = t
+		ce.Write(fieldsToZapFields(fields)...)
+	}
+}
+
 // Unwrap returns the underlying zap.Logger.
 // This is useful for cases where you need direct access to zap-specific features.
 func (z *ZapAdapter) Unwrap() *zap.Logger {
 	return z.logger
 }
 
+// levelOverrideCore is a zapcore.Core that replaces the minimum level of the wrapped core.
+// Entries accepted by the override are written directly to the wrapped core,
+// bypassing its own level check.
+type levelOverrideCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func newLevelOverrideCore(core zapcore.Core, level zapcore.Level) zapcore.Core {
+	return &levelOverrideCore{Core: unwrapLevelCore(core), level: level}
+}
+
+// Enabled reports whether the level is enabled by the override.
+func (c *levelOverrideCore) Enabled(level zapcore.Level) bool {
+	return level >= c.level
+}
+
+// Level returns the minimum enabled level.
+func (c *levelOverrideCore) Level() zapcore.Level {
+	return c.level
+}
+
+// With adds structured context to the wrapped core, keeping the override.
+func (c *levelOverrideCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelOverrideCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Check adds the core to the checked entry if the entry level is enabled by the override.
+func (c *levelOverrideCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// levelControllerCore is a zapcore.Core whose levels are decided by a LevelController.
+// Entries accepted by the controller are written directly to the wrapped core,
+// bypassing its own level check.
+type levelControllerCore struct {
+	zapcore.Core
+	controller *LevelController
+}
+
+// Enabled reports whether the level is enabled for at least one logger name.
+// The exact decision is made in Check, where the logger name is known.
+func (c *levelControllerCore) Enabled(level zapcore.Level) bool {
+	return Level(level) >= c.controller.minLevel()
+}
+
+// With adds structured context to the wrapped core, keeping the controller.
+func (c *levelControllerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelControllerCore{Core: c.Core.With(fields), controller: c.controller}
+}
+
+// Check adds the core to the checked entry if the controller enables the entry.
+func (c *levelControllerCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.controller.Enabled(entry.LoggerName, Level(entry.Level)) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// unwrapLevelCore strips a level override or controller core, so they don't stack.
+func unwrapLevelCore(core zapcore.Core) zapcore.Core {
+	switch c := core.(type) {
+	case *levelOverrideCore:
+		return c.Core
+	case *levelControllerCore:
+		return c.Core
+	default:
+		return core
+	}
+}
+
 // fieldsToZapFields converts xlog.Field slice to zap.Field slice.
 func fieldsToZapFields(fields []xfield.Field) []zap.Field {
 	if len(fields) == 0 {
@@ -167,6 +289,12 @@ func fieldToZapField(f xfield.Field) zap.Field {
 	case xfield.ObjectType:
 		return zap.Any(f.Key, f.Interface)
 
+	case xfield.GroupType:
+		if fields, ok := f.Interface.([]xfield.Field); ok {
+			return zap.Dict(f.Key, fieldsToZapFields(fields)...)
+		}
+		return zap.Any(f.Key, f.Interface)
+
 	case xfield.AnyType:
 		return zap.Any(f.Key, f.Interface)
 
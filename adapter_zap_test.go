@@ -126,6 +126,8 @@ func mapZapLevel(level zapcore.Level) int {
 		return warnLevel
 	case zapcore.ErrorLevel:
 		return errorLevel
+	case zapcore.DPanicLevel:
+		return dpanicLevel
 	case zapcore.PanicLevel:
 		return panicLevel
 	case zapcore.FatalLevel:
@@ -18,6 +18,7 @@ const (
 	infoLevel  = iota
 	warnLevel  = iota
 	errorLevel = iota
+	dpanicLevel
 	panicLevel
 	fatalLevel
 )
@@ -130,6 +131,19 @@ func testAdapter(t *testing.T, initAdapter func(t *testing.T) (Logger, logObserv
 		assert.Equal(t, "test error", entries[0].ContextMap["error"])
 	})
 
+	t.Run("DPanic level", func(t *testing.T) {
+		adapter, getLogsFunc := initAdapter(t)
+
+		dl, ok := adapter.(DPanicLogger)
+		require.True(t, ok)
+		dl.DPanic("unexpected state", xfield.String("state", "closed"))
+
+		entries := getLogsFunc()
+		require.Len(t, entries, 1)
+		assert.EqualValues(t, dpanicLevel, entries[0].Level)
+		assert.Equal(t, "closed", entries[0].ContextMap["state"])
+	})
+
 	t.Run("Panic level", func(t *testing.T) {
 		adapter, getLogsFunc := initAdapter(t)
 
@@ -188,4 +202,23 @@ func testAdapter(t *testing.T, initAdapter func(t *testing.T) (Logger, logObserv
 		assert.Equal(t, now.UnixNano(), ctx["time"].(time.Time).UnixNano())
 		assert.Equal(t, 5*time.Second, ctx["duration"])
 	})
+	t.Run("Group field", func(t *testing.T) {
+		adapter, getLogsFunc := initAdapter(t)
+
+		adapter.Info("grouped",
+			xfield.Group("http",
+				xfield.String("method", "GET"),
+				xfield.Group("response", xfield.Int("status", 200)),
+			),
+		)
+
+		entries := getLogsFunc()
+		require.Len(t, entries, 1)
+		group, ok := entries[0].ContextMap["http"].(map[string]any)
+		require.True(t, ok, "%#v", entries[0].ContextMap["http"])
+		assert.Equal(t, "GET", group["method"])
+		response, ok := group["response"].(map[string]any)
+		require.True(t, ok, "%#v", group["response"])
+		assert.EqualValues(t, 200, response["status"])
+	})
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ruko1202/xlog"
 	"github.com/ruko1202/xlog/xfield"
+	"github.com/ruko1202/xlog/xhttp"
 )
 
 func runWorker(ctx context.Context) {
@@ -24,7 +25,7 @@ func task(ctx context.Context, i int) {
 	userID := i
 	fail := i%3 == 0
 
-	req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/api/work", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/api/work", nil)
 	if err != nil {
 		xlog.Error(ctx, "create request failed", xfield.Error(err))
 		return
@@ -36,7 +37,7 @@ func task(ctx context.Context, i int) {
 	}
 	req.URL.RawQuery = query.Encode()
 
-	client := http.DefaultClient
+	client := &http.Client{Transport: xhttp.NewTransport(nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		xlog.Error(ctx, "do request failed", xfield.Error(err))
@@ -0,0 +1,135 @@
+package xlog
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Level is a logging priority. Higher levels are more important.
+// The values match zapcore.Level, so conversion between them is lossless.
+type Level int8
+
+const (
+	// DebugLevel logs are typically voluminous, and are usually disabled in production.
+	DebugLevel Level = iota - 1
+	// InfoLevel is the default logging priority.
+	InfoLevel
+	// WarnLevel logs are more important than Info, but don't need individual human review.
+	WarnLevel
+	// ErrorLevel logs are high-priority. If an application is running smoothly,
+	// it shouldn't generate any error-level logs.
+	ErrorLevel
+	// DPanicLevel logs are particularly important errors. In development the
+	// logger panics after writing the message.
+	DPanicLevel
+	// PanicLevel logs a message, then panics.
+	PanicLevel
+	// FatalLevel logs a message, then calls os.Exit(1).
+	FatalLevel
+)
+
+// String returns a lower-case ASCII representation of the log level.
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case DPanicLevel:
+		return "dpanic"
+	case PanicLevel:
+		return "panic"
+	case FatalLevel:
+		return "fatal"
+	default:
+		return fmt.Sprintf("Level(%d)", l)
+	}
+}
+
+// Enabled reports whether the given level is enabled when l is the minimum level.
+func (l Level) Enabled(level Level) bool {
+	return level >= l
+}
+
+// MarshalText marshals the Level to text.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText unmarshals text to a level.
+// Both lower- and upper-case names are accepted.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = level
+	return nil
+}
+
+// ParseLevel parses a level based on its lower-case or upper-case name.
+// "warning" is accepted as an alias of "warn". An empty name is an error.
+func ParseLevel(text string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "":
+		return InfoLevel, fmt.Errorf("empty level")
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "dpanic":
+		return DPanicLevel, nil
+	case "panic":
+		return PanicLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("unrecognized level: %q", text)
+	}
+}
+
+// zapLevel converts the Level to zapcore.Level.
+func (l Level) zapLevel() zapcore.Level {
+	return zapcore.Level(l)
+}
+
+// slogLevel converts the Level to slog.Level.
+// Levels above Error have no slog counterpart and are mapped to slog.LevelError.
+func (l Level) slogLevel() slog.Level {
+	switch {
+	case l <= DebugLevel:
+		return slog.LevelDebug
+	case l == InfoLevel:
+		return slog.LevelInfo
+	case l == WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// levelFromSlog converts slog.Level to the closest Level.
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
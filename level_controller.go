@@ -0,0 +1,326 @@
+package xlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelEnvVar is the environment variable read by LevelControllerFromEnv.
+const LevelEnvVar = "XLOG_LEVEL"
+
+// maxLevelsBodySize limits the size of the bodies accepted by LevelController.ServeHTTP.
+const maxLevelsBodySize = 64 << 10
+
+// LevelController holds a default level and per-logger overrides that can be changed at runtime.
+// Overrides are matched against the logger name built by Named (e.g. "http.client"):
+// an override applies to the logger with that name and all its descendants,
+// and the longest matching name wins.
+//
+// LevelController implements http.Handler, see ServeHTTP.
+//
+// Example:
+//
+//	levels := xlog.NewLevelController(xlog.InfoLevel)
+//	levels.SetLevel("db", xlog.DebugLevel)
+//	logger := levels.Apply(xlog.NewZapAdapter(zapLogger))
+type LevelController struct {
+	mu    sync.Mutex // serializes writers
+	state atomic.Pointer[levelState]
+}
+
+// levelState is an immutable snapshot of the controller configuration.
+type levelState struct {
+	defaultLevel Level
+	overrides    map[string]Level
+	minLevel     Level
+}
+
+func newLevelState(defaultLevel Level, overrides map[string]Level) *levelState {
+	state := &levelState{
+		defaultLevel: defaultLevel,
+		overrides:    overrides,
+		minLevel:     defaultLevel,
+	}
+	for _, level := range overrides {
+		if level < state.minLevel {
+			state.minLevel = level
+		}
+	}
+	return state
+}
+
+func (s *levelState) level(name string) Level {
+	for name != "" {
+		if level, ok := s.overrides[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return s.defaultLevel
+}
+
+// NewLevelController creates a controller with the given default level and no overrides.
+func NewLevelController(defaultLevel Level) *LevelController {
+	c := &LevelController{}
+	c.state.Store(newLevelState(defaultLevel, nil))
+	return c
+}
+
+// LevelControllerFromEnv creates a controller configured by the XLOG_LEVEL environment variable.
+// See ParseLevelSpec for the format. If the variable is empty, the default level is Info.
+func LevelControllerFromEnv() (*LevelController, error) {
+	c := NewLevelController(InfoLevel)
+	if err := c.Set(os.Getenv(LevelEnvVar)); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", LevelEnvVar, err)
+	}
+	return c, nil
+}
+
+// ParseLevelSpec parses a level specification such as "info,db=debug,http.client=warn".
+// An entry without a name sets the default level; "name=level" entries set overrides.
+// An empty specification means Info with no overrides.
+func ParseLevelSpec(spec string) (Level, map[string]Level, error) {
+	defaultLevel := InfoLevel
+	overrides := make(map[string]Level)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(entry, "=")
+		level, err := ParseLevel(value)
+		if !found {
+			level, err = ParseLevel(name)
+		}
+		if err != nil {
+			return InfoLevel, nil, err
+		}
+
+		name = strings.TrimSpace(name)
+		if !found || name == "*" {
+			defaultLevel = level
+			continue
+		}
+		if name == "" {
+			return InfoLevel, nil, fmt.Errorf("empty logger name in %q", entry)
+		}
+		overrides[name] = level
+	}
+
+	return defaultLevel, overrides, nil
+}
+
+// Set replaces the whole configuration with the given level specification.
+// See ParseLevelSpec for the format.
+func (c *LevelController) Set(spec string) error {
+	defaultLevel, overrides, err := ParseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.state.Store(newLevelState(defaultLevel, overrides))
+	c.mu.Unlock()
+	return nil
+}
+
+// String returns the configuration as a level specification, with overrides sorted by name.
+func (c *LevelController) String() string {
+	state := c.state.Load()
+
+	names := make([]string, 0, len(state.overrides))
+	for name := range state.overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(state.defaultLevel.String())
+	for _, name := range names {
+		b.WriteString(",")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(state.overrides[name].String())
+	}
+	return b.String()
+}
+
+// DefaultLevel returns the level used by loggers without a matching override.
+func (c *LevelController) DefaultLevel() Level {
+	return c.state.Load().defaultLevel
+}
+
+// SetDefaultLevel changes the level used by loggers without a matching override.
+func (c *LevelController) SetDefaultLevel(level Level) {
+	c.update(func(defaultLevel *Level, _ map[string]Level) {
+		*defaultLevel = level
+	})
+}
+
+// SetLevel sets the override for the named logger and its descendants.
+func (c *LevelController) SetLevel(name string, level Level) {
+	c.update(func(_ *Level, overrides map[string]Level) {
+		overrides[name] = level
+	})
+}
+
+// UnsetLevel removes the override for the named logger.
+func (c *LevelController) UnsetLevel(name string) {
+	c.update(func(_ *Level, overrides map[string]Level) {
+		delete(overrides, name)
+	})
+}
+
+// Levels returns a copy of the per-logger overrides.
+func (c *LevelController) Levels() map[string]Level {
+	state := c.state.Load()
+
+	levels := make(map[string]Level, len(state.overrides))
+	for name, level := range state.overrides {
+		levels[name] = level
+	}
+	return levels
+}
+
+// Level returns the effective level of the named logger.
+func (c *LevelController) Level(name string) Level {
+	return c.state.Load().level(name)
+}
+
+// Enabled reports whether the named logger writes entries at the given level.
+func (c *LevelController) Enabled(name string, level Level) bool {
+	state := c.state.Load()
+	if level < state.minLevel {
+		return false
+	}
+	return level >= state.level(name)
+}
+
+// Apply attaches the controller to the logger if it implements LevelControllable.
+// Other loggers are returned unchanged.
+func (c *LevelController) Apply(logger Logger) Logger {
+	if controllable, ok := logger.(LevelControllable); ok {
+		return controllable.WithLevelController(c)
+	}
+	return logger
+}
+
+// minLevel returns the lowest level enabled for any logger.
+func (c *LevelController) minLevel() Level {
+	return c.state.Load().minLevel
+}
+
+func (c *LevelController) update(fn func(defaultLevel *Level, overrides map[string]Level)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state.Load()
+	defaultLevel := state.defaultLevel
+	overrides := c.Levels()
+	fn(&defaultLevel, overrides)
+	c.state.Store(newLevelState(defaultLevel, overrides))
+}
+
+// levelsPayload is the JSON representation used by ServeHTTP.
+type levelsPayload struct {
+	Level   *Level            `json:"level,omitempty"`
+	Loggers map[string]*Level `json:"loggers,omitempty"`
+}
+
+// normalize checks and trims the logger names like ParseLevelSpec, moving "*" to the default level.
+func (p *levelsPayload) normalize() error {
+	loggers := make(map[string]*Level, len(p.Loggers))
+	for name, level := range p.Loggers {
+		switch trimmed := strings.TrimSpace(name); {
+		case trimmed == "":
+			return fmt.Errorf("empty logger name %q", name)
+		case trimmed == "*":
+			if level == nil {
+				return errors.New(`the default level "*" can't be removed`)
+			}
+			p.Level = level
+		default:
+			loggers[trimmed] = level
+		}
+	}
+	p.Loggers = loggers
+	return nil
+}
+
+type errorPayload struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP is a simple JSON endpoint that can report on or change the levels.
+//
+// GET returns the current configuration:
+//
+//	{"level":"info","loggers":{"db":"debug"}}
+//
+// PUT changes the default level and/or overrides and returns the new configuration.
+// Omitted values are left unchanged and a null override removes it. Logger names are
+// checked like in ParseLevelSpec: they can't be empty, and "*" sets the default level.
+// Bodies over 64 KiB are rejected:
+//
+//	{"level":"warn","loggers":{"db":"debug","http.client":null}}
+func (c *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var payload levelsPayload
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelsBodySize)).Decode(&payload); err != nil {
+			status := http.StatusBadRequest
+			if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSON(w, status, errorPayload{Error: fmt.Sprintf("invalid request body: %v", err)})
+			return
+		}
+		if err := payload.normalize(); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorPayload{Error: err.Error()})
+			return
+		}
+		c.update(func(defaultLevel *Level, overrides map[string]Level) {
+			if payload.Level != nil {
+				*defaultLevel = *payload.Level
+			}
+			for name, level := range payload.Loggers {
+				if level == nil {
+					delete(overrides, name)
+					continue
+				}
+				overrides[name] = *level
+			}
+		})
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, errorPayload{Error: "only GET and PUT are supported"})
+		return
+	}
+
+	state := c.state.Load()
+	loggers := make(map[string]*Level, len(state.overrides))
+	for name, level := range state.overrides {
+		loggers[name] = &level
+	}
+	writeJSON(w, http.StatusOK, levelsPayload{Level: &state.defaultLevel, Loggers: loggers})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
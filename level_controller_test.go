@@ -0,0 +1,247 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	t.Run("default and overrides", func(t *testing.T) {
+		defaultLevel, overrides, err := ParseLevelSpec("warn, db=debug,http.client=error")
+		require.NoError(t, err)
+		assert.Equal(t, WarnLevel, defaultLevel)
+		assert.Equal(t, map[string]Level{"db": DebugLevel, "http.client": ErrorLevel}, overrides)
+	})
+
+	t.Run("empty spec", func(t *testing.T) {
+		defaultLevel, overrides, err := ParseLevelSpec("")
+		require.NoError(t, err)
+		assert.Equal(t, InfoLevel, defaultLevel)
+		assert.Empty(t, overrides)
+	})
+
+	t.Run("wildcard sets default", func(t *testing.T) {
+		defaultLevel, _, err := ParseLevelSpec("*=error")
+		require.NoError(t, err)
+		assert.Equal(t, ErrorLevel, defaultLevel)
+	})
+
+	t.Run("invalid specs", func(t *testing.T) {
+		for _, spec := range []string{"verbose", "db=verbose", "=debug", "db=", "*="} {
+			_, _, err := ParseLevelSpec(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestLevelController(t *testing.T) {
+	t.Run("prefix matching with longest name", func(t *testing.T) {
+		c := NewLevelController(InfoLevel)
+		c.SetLevel("http", WarnLevel)
+		c.SetLevel("http.client", DebugLevel)
+
+		assert.Equal(t, InfoLevel, c.Level(""))
+		assert.Equal(t, InfoLevel, c.Level("db"))
+		assert.Equal(t, WarnLevel, c.Level("http"))
+		assert.Equal(t, WarnLevel, c.Level("http.server"))
+		assert.Equal(t, DebugLevel, c.Level("http.client"))
+		assert.Equal(t, DebugLevel, c.Level("http.client.retry"))
+		assert.Equal(t, InfoLevel, c.Level("httpx"))
+
+		assert.True(t, c.Enabled("http.client", DebugLevel))
+		assert.False(t, c.Enabled("http.server", InfoLevel))
+	})
+
+	t.Run("set, unset and string", func(t *testing.T) {
+		c := NewLevelController(InfoLevel)
+		require.NoError(t, c.Set("error,db=debug,cache=warn"))
+		assert.Equal(t, "error,cache=warn,db=debug", c.String())
+
+		c.UnsetLevel("cache")
+		c.SetDefaultLevel(InfoLevel)
+		assert.Equal(t, "info,db=debug", c.String())
+		assert.Equal(t, map[string]Level{"db": DebugLevel}, c.Levels())
+
+		assert.Error(t, c.Set("nope"))
+		assert.Equal(t, "info,db=debug", c.String())
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv(LevelEnvVar, "warn,db=debug")
+
+		c, err := LevelControllerFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, WarnLevel, c.DefaultLevel())
+		assert.Equal(t, DebugLevel, c.Level("db.query"))
+
+		t.Setenv(LevelEnvVar, "db=loud")
+		_, err = LevelControllerFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		c := NewLevelController(InfoLevel)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				c.SetLevel("db", DebugLevel)
+				c.UnsetLevel("db")
+			}()
+			go func() {
+				defer wg.Done()
+				_ = c.Enabled("db", DebugLevel)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestLevelController_Adapters(t *testing.T) {
+	t.Run("zap adapter", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		c := NewLevelController(WarnLevel)
+		logger = c.Apply(logger)
+
+		db := logger.Named("db")
+		client := logger.Named("http").Named("client")
+
+		logger.Info("hidden")
+		db.Debug("hidden")
+		client.Debug("hidden")
+
+		c.SetLevel("db", DebugLevel)
+		db.Debug("db debug")
+		db.Named("query").With().Debug("db query debug")
+		client.Warn("client warn")
+		logger.Info("still hidden")
+
+		require.Equal(t, 3, logs.Len())
+		assert.Equal(t, "db", logs.All()[0].LoggerName)
+		assert.Equal(t, "db.query", logs.All()[1].LoggerName)
+		assert.Equal(t, "http.client", logs.All()[2].LoggerName)
+	})
+
+	t.Run("slog adapter", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+		c := NewLevelController(WarnLevel)
+		logger := c.Apply(NewSlogAdapter(slog.New(handler)))
+
+		db := logger.Named("db")
+		logger.Info("hidden")
+		db.Debug("hidden")
+
+		c.SetLevel("db", DebugLevel)
+		db.Named("query").Debug("db query debug")
+		logger.Info("still hidden")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], `"msg":"db query debug"`)
+	})
+
+	t.Run("context level takes precedence", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		logger = NewLevelController(ErrorLevel).Apply(logger)
+
+		logger.(LevelOverrider).WithLevelOverride(DebugLevel).Debug("visible")
+		require.Equal(t, 1, logs.Len())
+	})
+
+	t.Run("unsupported logger is returned unchanged", func(t *testing.T) {
+		logger := NewNoopLogger()
+		assert.Same(t, logger, NewLevelController(InfoLevel).Apply(logger))
+	})
+}
+
+func TestLevelController_ServeHTTP(t *testing.T) {
+	c := NewLevelController(InfoLevel)
+	c.SetLevel("db", DebugLevel)
+
+	serve := func(method, body string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		c.ServeHTTP(rec, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+		return rec, payload
+	}
+
+	t.Run("get", func(t *testing.T) {
+		rec, payload := serve(http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "info", payload["level"])
+		assert.Equal(t, map[string]any{"db": "debug"}, payload["loggers"])
+	})
+
+	t.Run("put", func(t *testing.T) {
+		rec, payload := serve(http.MethodPut, `{"level":"warn","loggers":{"db":null,"http.client":"debug"}}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "warn", payload["level"])
+		assert.Equal(t, map[string]any{"http.client": "debug"}, payload["loggers"])
+		assert.Equal(t, "warn,http.client=debug", c.String())
+	})
+
+	t.Run("put keeps omitted values", func(t *testing.T) {
+		_, payload := serve(http.MethodPut, `{"loggers":{"db":"error"}}`)
+		assert.Equal(t, "warn", payload["level"])
+		assert.Equal(t, "warn,db=error,http.client=debug", c.String())
+	})
+
+	t.Run("put with invalid level", func(t *testing.T) {
+		rec, payload := serve(http.MethodPut, `{"level":"loud"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, payload["error"], "unrecognized level")
+		assert.Equal(t, "warn,db=error,http.client=debug", c.String())
+	})
+
+	t.Run("put with empty level", func(t *testing.T) {
+		rec, payload := serve(http.MethodPut, `{"loggers":{"db":""}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, payload["error"], "empty level")
+		assert.Equal(t, "warn,db=error,http.client=debug", c.String())
+	})
+
+	t.Run("put with invalid logger names", func(t *testing.T) {
+		for _, body := range []string{`{"loggers":{"":"debug"}}`, `{"loggers":{" ":"debug"}}`, `{"loggers":{"*":null}}`} {
+			rec, payload := serve(http.MethodPut, body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+			assert.NotEmpty(t, payload["error"], body)
+		}
+		assert.Equal(t, "warn,db=error,http.client=debug", c.String())
+	})
+
+	t.Run("put trims names and sets the default level with *", func(t *testing.T) {
+		_, payload := serve(http.MethodPut, `{"loggers":{" db ":"info","*":"error"}}`)
+		assert.Equal(t, "error", payload["level"])
+		assert.Equal(t, "error,db=info,http.client=debug", c.String())
+		c.SetDefaultLevel(WarnLevel)
+	})
+
+	t.Run("put with a body too large", func(t *testing.T) {
+		body := `{"loggers":{"db":"debug"},"padding":"` + strings.Repeat("x", maxLevelsBodySize) + `"}`
+		rec, _ := serve(http.MethodPut, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Equal(t, "warn,db=info,http.client=debug", c.String())
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		rec, _ := serve(http.MethodDelete, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, PUT", rec.Header().Get("Allow"))
+	})
+}
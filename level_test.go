@@ -0,0 +1,75 @@
+package xlog
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevel(t *testing.T) {
+	t.Run("string and parse round trip", func(t *testing.T) {
+		for _, level := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
+			parsed, err := ParseLevel(level.String())
+			require.NoError(t, err)
+			assert.Equal(t, level, parsed)
+		}
+	})
+
+	t.Run("parse accepts aliases and upper case", func(t *testing.T) {
+		level, err := ParseLevel("WARNING")
+		require.NoError(t, err)
+		assert.Equal(t, WarnLevel, level)
+
+		level, err = ParseLevel(" Debug ")
+		require.NoError(t, err)
+		assert.Equal(t, DebugLevel, level)
+	})
+
+	t.Run("parse rejects unknown level", func(t *testing.T) {
+		_, err := ParseLevel("verbose")
+		assert.Error(t, err)
+	})
+
+	t.Run("parse rejects empty level", func(t *testing.T) {
+		_, err := ParseLevel(" ")
+		assert.Error(t, err)
+
+		var level Level
+		assert.Error(t, level.UnmarshalText(nil))
+	})
+
+	t.Run("text marshaling", func(t *testing.T) {
+		text, err := ErrorLevel.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, "error", string(text))
+
+		var level Level
+		require.NoError(t, level.UnmarshalText([]byte("debug")))
+		assert.Equal(t, DebugLevel, level)
+		assert.Error(t, level.UnmarshalText([]byte("nope")))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assert.True(t, InfoLevel.Enabled(ErrorLevel))
+		assert.True(t, InfoLevel.Enabled(InfoLevel))
+		assert.False(t, InfoLevel.Enabled(DebugLevel))
+	})
+
+	t.Run("backend conversions", func(t *testing.T) {
+		assert.Equal(t, zapcore.DebugLevel, DebugLevel.zapLevel())
+		assert.Equal(t, zapcore.DPanicLevel, DPanicLevel.zapLevel())
+		assert.Equal(t, zapcore.FatalLevel, FatalLevel.zapLevel())
+
+		assert.Equal(t, slog.LevelDebug, DebugLevel.slogLevel())
+		assert.Equal(t, slog.LevelInfo, InfoLevel.slogLevel())
+		assert.Equal(t, slog.LevelWarn, WarnLevel.slogLevel())
+		assert.Equal(t, slog.LevelError, FatalLevel.slogLevel())
+	})
+
+	t.Run("unknown level string", func(t *testing.T) {
+		assert.Equal(t, "Level(42)", Level(42).String())
+	})
+}
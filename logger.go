@@ -5,11 +5,46 @@ import (
 	"fmt"
 
 	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/ruko1202/xlog/xfield"
 )
 
+// Log logs a message with structured fields at the given level, like the helper of that level.
+// Logger is extracted from context. If logger is not found, the global logger is used.
+// It is meant for levels chosen at runtime, e.g. by configuration.
+//
+// Example:
+//
+//	xlog.Log(ctx, cfg.AccessLogLevel, "request completed", xlog.Int("status", status))
+func Log(ctx context.Context, level Level, msg string, fields ...xfield.Field) {
+	logger, fields := helperEntry(ctx, level, msg, fields)
+	switch {
+	case level <= DebugLevel:
+		logger.Debug(msg, fields...)
+	case level == InfoLevel:
+		logger.Info(msg, fields...)
+	case level == WarnLevel:
+		logger.Warn(msg, fields...)
+	case level == DPanicLevel:
+		if dl, ok := logger.(DPanicLogger); ok {
+			dl.DPanic(msg, fields...)
+			return
+		}
+		logger.Error(msg, fields...)
+		if IsDevelopment() {
+			panic(msg)
+		}
+	case level < DPanicLevel:
+		logger.Error(msg, fields...)
+	case level == PanicLevel:
+		logger.Panic(msg, fields...)
+	default:
+		logger.Fatal(msg, fields...)
+	}
+}
+
 // Debug logs a Debug level message with structured fields.
 // Logger is extracted from context. If logger is not found, the global logger is used.
 //
@@ -17,8 +52,8 @@ import (
 //
 //	xlog.Debug(ctx, "debug message", xlog.String("key", "value"))
 func Debug(ctx context.Context, msg string, fields ...xfield.Field) {
-	logger := loggerFromContext(ctx)
-	logger.Debug(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, DebugLevel, msg, fields)
+	logger.Debug(msg, fields...)
 }
 
 // Debugf logs a formatted Debug level message.
@@ -28,7 +63,9 @@ func Debug(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Debugf(ctx, "value: %d, status: %s", 42, "ok")
 func Debugf(ctx context.Context, template string, args ...any) {
-	Debug(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, DebugLevel, msg, nil)
+	logger.Debug(msg, fields...)
 }
 
 // Info logs an Info level message with structured fields.
@@ -38,8 +75,8 @@ func Debugf(ctx context.Context, template string, args ...any) {
 //
 //	xlog.Info(ctx, "request processed", xlog.Duration("took", time.Second))
 func Info(ctx context.Context, msg string, fields ...xfield.Field) {
-	logger := loggerFromContext(ctx)
-	logger.Info(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, InfoLevel, msg, fields)
+	logger.Info(msg, fields...)
 }
 
 // Infof logs a formatted Info level message.
@@ -49,7 +86,9 @@ func Info(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Infof(ctx, "user %s logged in", userID)
 func Infof(ctx context.Context, template string, args ...any) {
-	Info(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, InfoLevel, msg, nil)
+	logger.Info(msg, fields...)
 }
 
 // Warn logs a Warn level message with structured fields.
@@ -59,10 +98,8 @@ func Infof(ctx context.Context, template string, args ...any) {
 //
 //	xlog.Warn(ctx, "slow query", xlog.Duration("took", time.Second*5))
 func Warn(ctx context.Context, msg string, fields ...xfield.Field) {
-	markSpanError(ctx, msg, fields)
-
-	logger := loggerFromContext(ctx)
-	logger.Warn(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, WarnLevel, msg, fields)
+	logger.Warn(msg, fields...)
 }
 
 // Warnf logs a formatted Warn level message.
@@ -72,7 +109,9 @@ func Warn(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Warnf(ctx, "retry attempts: %d", retryCount)
 func Warnf(ctx context.Context, template string, args ...any) {
-	Warn(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, WarnLevel, msg, nil)
+	logger.Warn(msg, fields...)
 }
 
 // Error logs an Error level message with structured fields.
@@ -82,10 +121,8 @@ func Warnf(ctx context.Context, template string, args ...any) {
 //
 //	xlog.Error(ctx, "database query error", xlog.Error(err))
 func Error(ctx context.Context, msg string, fields ...xfield.Field) {
-	markSpanError(ctx, msg, fields)
-
-	logger := loggerFromContext(ctx)
-	logger.Error(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, ErrorLevel, msg, fields)
+	logger.Error(msg, fields...)
 }
 
 // Errorf logs a formatted Error level message.
@@ -95,7 +132,48 @@ func Error(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Errorf(ctx, "failed to process request: %v", err)
 func Errorf(ctx context.Context, template string, args ...any) {
-	Error(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, ErrorLevel, msg, nil)
+	logger.Error(msg, fields...)
+}
+
+// DPanic logs a DPanic level message with structured fields and, in development, panics.
+// Logger is extracted from context. If logger is not found, the global logger is used.
+// Loggers implementing DPanicLogger decide whether to panic, others log the message
+// at Error level and panic if the global development switch is on, see ReplaceDevelopment.
+//
+// Example:
+//
+//	xlog.DPanic(ctx, "unexpected state", xlog.String("state", state))
+func DPanic(ctx context.Context, msg string, fields ...xfield.Field) {
+	logger, fields := helperEntry(ctx, DPanicLevel, msg, fields)
+	if dl, ok := logger.(DPanicLogger); ok {
+		dl.DPanic(msg, fields...)
+		return
+	}
+	logger.Error(msg, fields...)
+	if IsDevelopment() {
+		panic(msg)
+	}
+}
+
+// DPanicf logs a formatted DPanic level message and, in development, panics.
+// Logger is extracted from context. If logger is not found, the global logger is used.
+//
+// Example:
+//
+//	xlog.DPanicf(ctx, "unexpected state: %v", state)
+func DPanicf(ctx context.Context, template string, args ...any) {
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, DPanicLevel, msg, nil)
+	if dl, ok := logger.(DPanicLogger); ok {
+		dl.DPanic(msg, fields...)
+		return
+	}
+	logger.Error(msg, fields...)
+	if IsDevelopment() {
+		panic(msg)
+	}
 }
 
 // Fatal logs a Fatal level message with structured fields and terminates the program.
@@ -106,10 +184,8 @@ func Errorf(ctx context.Context, template string, args ...any) {
 //
 //	xlog.Fatal(ctx, "critical error", xlog.Error(err))
 func Fatal(ctx context.Context, msg string, fields ...xfield.Field) {
-	markSpanError(ctx, msg, fields)
-
-	logger := loggerFromContext(ctx)
-	logger.Fatal(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, FatalLevel, msg, fields)
+	logger.Fatal(msg, fields...)
 }
 
 // Fatalf logs a formatted Fatal level message and terminates the program.
@@ -120,7 +196,9 @@ func Fatal(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Fatalf(ctx, "failed to start server: %v", err)
 func Fatalf(ctx context.Context, template string, args ...any) {
-	Fatal(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, FatalLevel, msg, nil)
+	logger.Fatal(msg, fields...)
 }
 
 // Panic logs a Panic level message with structured fields and panics.
@@ -130,10 +208,8 @@ func Fatalf(ctx context.Context, template string, args ...any) {
 //
 //	xlog.Panic(ctx, "unexpected state", xlog.String("state", state))
 func Panic(ctx context.Context, msg string, fields ...xfield.Field) {
-	markSpanError(ctx, msg, fields)
-
-	logger := loggerFromContext(ctx)
-	logger.Panic(msg, withMetadataFields(ctx, fields)...)
+	logger, fields := helperEntry(ctx, PanicLevel, msg, fields)
+	logger.Panic(msg, fields...)
 }
 
 // Panicf logs a formatted Panic level message and panics.
@@ -143,10 +219,14 @@ func Panic(ctx context.Context, msg string, fields ...xfield.Field) {
 //
 //	xlog.Panicf(ctx, "invalid value: %v", value)
 func Panicf(ctx context.Context, template string, args ...any) {
-	Panic(ctx, fmt.Sprintf(template, args...))
+	msg := fmt.Sprintf(template, args...)
+	logger, fields := helperEntry(ctx, PanicLevel, msg, nil)
+	logger.Panic(msg, fields...)
 }
 
 func withMetadataFields(ctx context.Context, fields []xfield.Field) []xfield.Field {
+	fields = withErrorDetails(fields)
+
 	traceFields := traceMetadataFields(ctx)
 	if len(traceFields) == 0 {
 		return fields
@@ -173,19 +253,93 @@ func traceMetadataFields(ctx context.Context) []xfield.Field {
 	return nil
 }
 
-func markSpanError(ctx context.Context, msg string, fields []xfield.Field) {
+// helperEntry prepares the entry of a package-level helper: it returns the logger from the context
+// and the fields with the stack trace enabled by ReplaceStacktraceLevel, the error details and the trace
+// metadata. Warnings and above with an error mark the span as failed. The helpers call the returned
+// logger themselves, so a logger reporting its caller skips as many frames as before, one for the helper.
+// helperEntry must be called directly by the helpers, so the stack trace starts at their caller.
+func helperEntry(ctx context.Context, level Level, msg string, fields []xfield.Field) (Logger, []xfield.Field) {
+	var stack string
+	if level >= stacktraceLevel() {
+		stackField := xfield.StackSkip(stacktraceKey, 2) // skip helperEntry and the helper
+		stack = stackField.String
+		fields = append(fields[:len(fields):len(fields)], stackField)
+	}
+
+	if level >= WarnLevel {
+		markSpanError(ctx, msg, fields, stack)
+	}
+
+	logger := loggerFromContext(ctx)
+	if cl, ok := logger.(contextLogger); ok {
+		logger = contextBoundLogger{Logger: logger, ctx: ctx, cl: cl}
+	}
+	return logger, withMetadataFields(ctx, fields)
+}
+
+// contextBoundLogger passes the context of a package-level helper to a contextLogger.
+type contextBoundLogger struct {
+	Logger
+	ctx context.Context
+	cl  contextLogger
+}
+
+func (l contextBoundLogger) Debug(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, DebugLevel, msg, fields)
+}
+
+func (l contextBoundLogger) Info(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, InfoLevel, msg, fields)
+}
+
+func (l contextBoundLogger) Warn(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, WarnLevel, msg, fields)
+}
+
+func (l contextBoundLogger) Error(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, ErrorLevel, msg, fields)
+}
+
+func (l contextBoundLogger) DPanic(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, DPanicLevel, msg, fields)
+}
+
+func (l contextBoundLogger) Panic(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, PanicLevel, msg, fields)
+}
+
+func (l contextBoundLogger) Fatal(msg string, fields ...xfield.Field) {
+	l.cl.logContext(l.ctx, FatalLevel, msg, fields)
+}
+
+// markSpanError records the first error of the fields on the span and sets its status to Error.
+// The stack captured for the entry, if any, is reused for the exception event.
+func markSpanError(ctx context.Context, msg string, fields []xfield.Field, stack string) {
 	span := SpanFromContext(ctx)
 	if span.IsRecording() {
 		for _, f := range fields {
 			if f.Type == xfield.ErrorType {
 				if err, ok := f.Interface.(error); ok && err != nil {
 					span.SetStatus(codes.Error, msg)
-					span.RecordError(err,
-						trace.WithStackTrace(true),
-					)
+					span.RecordError(err, errorEventOptions(err, stack)...)
 					break
 				}
 			}
 		}
 	}
 }
+
+// errorEventOptions returns the attributes of the exception event recorded for err:
+// the fields attached by NewErr and WrapErr, and the stack they captured,
+// or else the stack captured for the entry, or else the current stack.
+func errorEventOptions(err error, entryStack string) []trace.EventOption {
+	options := []trace.EventOption{trace.WithAttributes(fieldsToOtelAttributes(ErrorFields(err))...)}
+	switch stack := errorStack(err); {
+	case stack != nil:
+		return append(options, trace.WithAttributes(semconv.ExceptionStacktrace(xfield.FormatStack(stack))))
+	case entryStack != "":
+		return append(options, trace.WithAttributes(semconv.ExceptionStacktrace(entryStack)))
+	default:
+		return append(options, trace.WithStackTrace(true))
+	}
+}
@@ -0,0 +1,367 @@
+package xlog
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// OverflowPolicy decides what an AsyncLogger does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block waits until the background goroutine makes room in the buffer.
+	Block OverflowPolicy = iota
+	// DropNewest discards the entry being logged.
+	DropNewest
+	// DropOldest discards the oldest buffered entry to make room for the new one.
+	DropOldest
+)
+
+// DefaultAsyncBufferSize is the buffer size used when AsyncConfig.BufferSize is not set.
+const DefaultAsyncBufferSize = 1024
+
+// AsyncConfig configures an AsyncLogger.
+type AsyncConfig struct {
+	// BufferSize is the number of entries buffered before OnFull applies.
+	// The default is DefaultAsyncBufferSize.
+	BufferSize int
+	// OnFull is the policy applied when the buffer is full. The default is Block.
+	OnFull OverflowPolicy
+	// FlushInterval is how often the inner logger is synced in the background.
+	// Zero disables periodic syncing.
+	FlushInterval time.Duration
+}
+
+// AsyncLogger is a Logger handing entries to a background goroutine that writes them to
+// the inner logger, so slow outputs don't stall the callers. The native loggers and the zap
+// and slog adapters write the entries with the time of the call, not the time of the write.
+// Fields are copied at call time: slices, maps and byte slices held by fields may be
+// reused by the caller after logging, but values behind pointers are not copied.
+//
+// Sync drains the buffer before syncing the inner logger, and Fatal and Panic drain it
+// before writing their entry directly to the inner logger. Call Close at shutdown.
+//
+// Example:
+//
+//	logger := xlog.NewAsyncLogger(xlog.NewJSONLogger(os.Stdout), xlog.AsyncConfig{
+//		BufferSize:    4096,
+//		OnFull:        xlog.DropOldest,
+//		FlushInterval: time.Second,
+//	})
+//	defer logger.Close()
+type AsyncLogger struct {
+	core   *asyncCore
+	logger Logger
+}
+
+// asyncCore is the state shared by an AsyncLogger and all its children.
+type asyncCore struct {
+	inner  Logger
+	policy OverflowPolicy
+	queue  chan asyncEntry
+
+	sendMu   sync.Mutex // serializes producers, so entries are counted in queue order
+	enqueued uint64     // guarded by sendMu
+	closed   bool       // guarded by sendMu
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	processed uint64 // entries written or dropped from the queue, guarded by mu
+
+	dropped atomic.Uint64
+
+	wg sync.WaitGroup
+}
+
+type asyncEntry struct {
+	logger Logger
+	time   time.Time // the time of the call, zero if the inner logger isn't a timedLogger
+	level  Level
+	msg    string
+	fields []xfield.Field
+}
+
+// NewAsyncLogger creates an AsyncLogger writing to inner in a background goroutine.
+func NewAsyncLogger(inner Logger, cfg AsyncConfig) *AsyncLogger {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultAsyncBufferSize
+	}
+
+	core := &asyncCore{
+		inner:  inner,
+		policy: cfg.OnFull,
+		queue:  make(chan asyncEntry, cfg.BufferSize),
+	}
+	core.cond = sync.NewCond(&core.mu)
+
+	core.wg.Add(1)
+	go core.run(cfg.FlushInterval)
+
+	return &AsyncLogger{core: core, logger: inner}
+}
+
+// Debug logs a debug-level message.
+func (a *AsyncLogger) Debug(msg string, fields ...xfield.Field) {
+	a.log(DebugLevel, msg, fields)
+}
+
+// Info logs an info-level message.
+func (a *AsyncLogger) Info(msg string, fields ...xfield.Field) {
+	a.log(InfoLevel, msg, fields)
+}
+
+// Warn logs a warning-level message.
+func (a *AsyncLogger) Warn(msg string, fields ...xfield.Field) {
+	a.log(WarnLevel, msg, fields)
+}
+
+// Error logs an error-level message.
+func (a *AsyncLogger) Error(msg string, fields ...xfield.Field) {
+	a.log(ErrorLevel, msg, fields)
+}
+
+// Fatal drains the buffer, then logs a fatal-level message directly to the inner logger.
+func (a *AsyncLogger) Fatal(msg string, fields ...xfield.Field) {
+	a.core.drain()
+	a.logger.Fatal(msg, fields...)
+}
+
+// DPanic drains the buffer, then logs a dpanic-level message directly to the inner logger,
+// so it can panic in development.
+func (a *AsyncLogger) DPanic(msg string, fields ...xfield.Field) {
+	a.core.drain()
+	dpanic(a.logger, msg, fields)
+}
+
+// Panic drains the buffer, then logs a panic-level message directly to the inner logger.
+func (a *AsyncLogger) Panic(msg string, fields ...xfield.Field) {
+	a.core.drain()
+	a.logger.Panic(msg, fields...)
+}
+
+// With creates a child logger with additional fields.
+func (a *AsyncLogger) With(fields ...xfield.Field) Logger {
+	return &AsyncLogger{core: a.core, logger: a.logger.With(fields...)}
+}
+
+// Named creates a named child logger.
+func (a *AsyncLogger) Named(name string) Logger {
+	return &AsyncLogger{core: a.core, logger: a.logger.Named(name)}
+}
+
+// Sync waits until the entries logged before the call are written, then syncs the inner logger.
+func (a *AsyncLogger) Sync() error {
+	a.core.drain()
+	return a.logger.Sync()
+}
+
+// WithLevelOverride returns a child logger whose inner logger uses the given minimum level.
+func (a *AsyncLogger) WithLevelOverride(level Level) Logger {
+	return &AsyncLogger{core: a.core, logger: overrideLevel(a.logger, level)}
+}
+
+// WithLevelController returns a child logger whose inner logger levels are decided by the controller.
+func (a *AsyncLogger) WithLevelController(controller *LevelController) Logger {
+	return &AsyncLogger{core: a.core, logger: controller.Apply(a.logger)}
+}
+
+// Enabled reports whether the inner logger writes entries at the given level.
+func (a *AsyncLogger) Enabled(level Level) bool {
+	return levelEnabled(a.logger, level)
+}
+
+func (a *AsyncLogger) loggerName() string {
+	return loggerNameOf(a.logger)
+}
+
+// Dropped returns the number of entries discarded because the buffer was full.
+func (a *AsyncLogger) Dropped() uint64 {
+	return a.core.dropped.Load()
+}
+
+// Close writes the buffered entries, stops the background goroutine and syncs the inner logger.
+// Entries logged after Close are written synchronously.
+func (a *AsyncLogger) Close() error {
+	c := a.core
+
+	c.sendMu.Lock()
+	if c.closed {
+		c.sendMu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.queue)
+	c.sendMu.Unlock()
+
+	c.wg.Wait()
+	return c.inner.Sync()
+}
+
+// log queues the entry unless the inner logger discards its level, so disabled entries
+// neither take room in the buffer nor get their fields copied. The time of the call is
+// kept for the inner loggers that can write it (the native loggers and the zap and slog adapters).
+func (a *AsyncLogger) log(level Level, msg string, fields []xfield.Field) {
+	if !levelEnabled(a.logger, level) {
+		return
+	}
+	entry := asyncEntry{logger: a.logger, level: level, msg: msg, fields: snapshotFields(fields)}
+	if tl, ok := a.logger.(timedLogger); ok {
+		entry.time = tl.now()
+	}
+	a.core.enqueue(entry)
+}
+
+func (c *asyncCore) enqueue(entry asyncEntry) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.closed {
+		entry.write()
+		return
+	}
+
+	switch c.policy {
+	case DropNewest:
+		select {
+		case c.queue <- entry:
+		default:
+			c.dropped.Add(1)
+			return
+		}
+	case DropOldest:
+		for sent := false; !sent; {
+			select {
+			case c.queue <- entry:
+				sent = true
+			default:
+				select {
+				case <-c.queue:
+					c.dropped.Add(1)
+					c.markProcessed()
+				default: // the worker made room meanwhile
+				}
+			}
+		}
+	default:
+		c.queue <- entry
+	}
+	c.enqueued++
+}
+
+// drain waits until all the entries enqueued so far are written or dropped.
+func (c *asyncCore) drain() {
+	c.sendMu.Lock()
+	target := c.enqueued
+	c.sendMu.Unlock()
+
+	c.mu.Lock()
+	for c.processed < target {
+		c.cond.Wait()
+	}
+	c.mu.Unlock()
+}
+
+func (c *asyncCore) markProcessed() {
+	c.mu.Lock()
+	c.processed++
+	c.cond.Broadcast()
+	c.mu.Unlock()
+}
+
+func (c *asyncCore) run(flushInterval time.Duration) {
+	defer c.wg.Done()
+
+	var tick <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case entry, ok := <-c.queue:
+			if !ok {
+				return
+			}
+			entry.write()
+			c.markProcessed()
+		case <-tick:
+			_ = c.inner.Sync()
+		}
+	}
+}
+
+func (e *asyncEntry) write() {
+	if tl, ok := e.logger.(timedLogger); ok && !e.time.IsZero() {
+		tl.logAt(e.time, e.level, e.msg, e.fields)
+		return
+	}
+
+	switch e.level {
+	case DebugLevel:
+		e.logger.Debug(e.msg, e.fields...)
+	case InfoLevel:
+		e.logger.Info(e.msg, e.fields...)
+	case WarnLevel:
+		e.logger.Warn(e.msg, e.fields...)
+	default:
+		e.logger.Error(e.msg, e.fields...)
+	}
+}
+
+// snapshotFields copies the fields and the slices, maps and byte slices they hold,
+// so the caller can reuse them while the entry is waiting in the buffer.
+func snapshotFields(fields []xfield.Field) []xfield.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	snapshot := make([]xfield.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case xfield.GroupType:
+			if nested, ok := f.Interface.([]xfield.Field); ok {
+				f.Interface = snapshotFields(nested)
+			}
+		case xfield.BinaryType, xfield.ArrayType, xfield.ObjectType, xfield.AnyType:
+			f.Interface = snapshotValue(f.Interface)
+		}
+		snapshot[i] = f
+	}
+	return snapshot
+}
+
+// snapshotValue returns a shallow copy of slices and maps; other values are returned as is.
+func snapshotValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return append([]byte(nil), b...)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(cp, rv)
+		return cp.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return cp.Interface()
+	default:
+		return v
+	}
+}
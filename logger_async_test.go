@@ -0,0 +1,377 @@
+package xlog
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// gatedWriter blocks every write until the gate is opened, and counts syncs.
+type gatedWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	syncs int
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.gate
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncs++
+	return nil
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func (w *gatedWriter) Syncs() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncs
+}
+
+// gatedCore blocks every write until the gate is opened.
+type gatedCore struct {
+	zapcore.Core
+	gate    chan struct{}
+	started chan struct{}
+}
+
+func (c *gatedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *gatedCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	select {
+	case c.started <- struct{}{}:
+	default:
+	}
+	<-c.gate
+	return c.Core.Write(entry, fields)
+}
+
+func initAsyncLogger(t *testing.T, out *gatedWriter, cfg AsyncConfig) *AsyncLogger {
+	t.Helper()
+
+	inner := NewLogfmtLogger(out,
+		WithMinLevel(DebugLevel),
+		WithTimeKey(""),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	)
+	logger := NewAsyncLogger(inner, cfg)
+	t.Cleanup(func() {
+		select {
+		case <-out.gate:
+		default:
+			close(out.gate)
+		}
+		_ = logger.Close()
+	})
+	return logger
+}
+
+func TestAsyncLogger(t *testing.T) {
+	t.Run("writes in the background and sync drains", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		logger := initAsyncLogger(t, out, AsyncConfig{})
+
+		logger.Debug("debug")
+		logger.Named("http").With(xfield.Int("n", 1)).Info("info", xfield.String("k", "v"))
+		logger.Warn("warn")
+		logger.Error("error")
+		require.NoError(t, logger.Sync())
+
+		assert.Equal(t,
+			"level=debug msg=debug\nlevel=info logger=http msg=info n=1 k=v\nlevel=warn msg=warn\nlevel=error msg=error\n",
+			out.String(),
+		)
+		assert.Positive(t, out.Syncs())
+	})
+
+	t.Run("does not block the caller on a slow output", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 10})
+
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < 5; i++ {
+				logger.Info("entry")
+			}
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("logging blocked on the output")
+		}
+		assert.Empty(t, out.String())
+
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, 5, bytes.Count([]byte(out.String()), []byte("\n")))
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 2, OnFull: DropNewest})
+
+		logger.Info("0")
+		<-out.started // the worker holds entry 0
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			logger.Info(msg)
+		}
+		assert.Equal(t, uint64(2), logger.Dropped())
+
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, "level=info msg=0\nlevel=info msg=1\nlevel=info msg=2\n", out.String())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 2, OnFull: DropOldest})
+
+		logger.Info("0")
+		<-out.started
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			logger.Info(msg)
+		}
+		assert.Equal(t, uint64(2), logger.Dropped())
+
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, "level=info msg=0\nlevel=info msg=3\nlevel=info msg=4\n", out.String())
+	})
+
+	t.Run("block waits for room", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 1, OnFull: Block})
+
+		logger.Info("0")
+		<-out.started
+		logger.Info("1")
+
+		blocked := make(chan struct{})
+		go func() {
+			logger.Info("2")
+			close(blocked)
+		}()
+
+		select {
+		case <-blocked:
+			t.Fatal("logging didn't block on a full buffer")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(out.gate)
+		<-blocked
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, "level=info msg=0\nlevel=info msg=1\nlevel=info msg=2\n", out.String())
+		assert.Zero(t, logger.Dropped())
+	})
+
+	t.Run("does not queue disabled levels", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 2, OnFull: DropNewest})
+		infoLogger := logger.WithLevelOverride(InfoLevel)
+
+		infoLogger.Error("0")
+		<-out.started
+		for i := 0; i < 10; i++ {
+			infoLogger.Debug("disabled")
+		}
+		infoLogger.Error("1")
+		infoLogger.Error("2")
+		assert.Zero(t, logger.Dropped())
+		assert.False(t, infoLogger.(LevelEnabler).Enabled(DebugLevel))
+		assert.True(t, infoLogger.(LevelEnabler).Enabled(InfoLevel))
+
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, "level=error msg=0\nlevel=error msg=1\nlevel=error msg=2\n", out.String())
+	})
+
+	t.Run("keeps the time of the call", func(t *testing.T) {
+		out := newGatedWriter()
+		var now atomic.Int64
+		now.Store(testTime.UnixNano())
+		clock := func() time.Time { return time.Unix(0, now.Load()).UTC() }
+		logger := NewAsyncLogger(NewLogfmtLogger(out, WithClock(clock)), AsyncConfig{})
+		t.Cleanup(func() { _ = logger.Close() })
+
+		logger.Info("first")
+		<-out.started
+		now.Add(int64(time.Hour))
+		logger.Info("second")
+		now.Add(int64(time.Hour)) // the entries are written after that
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+
+		assert.Equal(t,
+			"time=2024-01-02T03:04:05.000006Z level=info msg=first\n"+
+				"time=2024-01-02T04:04:05.000006Z level=info msg=second\n",
+			out.String(),
+		)
+	})
+
+	t.Run("keeps the time of the call with zap", func(t *testing.T) {
+		observed, logs := observer.New(zapcore.InfoLevel)
+		core := &gatedCore{Core: observed, gate: make(chan struct{}), started: make(chan struct{}, 1)}
+		logger := NewAsyncLogger(NewZapAdapter(zap.New(core)), AsyncConfig{})
+		t.Cleanup(func() { _ = logger.Close() })
+
+		logger.Info("first")
+		<-core.started
+		logger.Info("second")
+		logged := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		close(core.gate)
+		require.NoError(t, logger.Sync())
+
+		require.Equal(t, 2, logs.Len())
+		assert.True(t, logs.All()[1].Time.Before(logged), "the time of the write: %s", logs.All()[1].Time)
+	})
+
+	t.Run("snapshots fields at call time", func(t *testing.T) {
+		out := newGatedWriter()
+		logger := initAsyncLogger(t, out, AsyncConfig{})
+
+		ints := []int{1, 2}
+		data := []byte("ab")
+		labels := map[string]string{"k": "v"}
+		fields := []xfield.Field{
+			xfield.Ints("ints", ints),
+			xfield.Binary("data", data),
+			xfield.Group("g", xfield.Any("labels", labels)),
+		}
+		logger.Info("snapshot", fields...)
+
+		ints[0] = 9
+		data[0] = 'z'
+		labels["k"] = "changed"
+		fields[0] = xfield.String("ints", "replaced")
+
+		close(out.gate)
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, `level=info msg=snapshot ints="[1 2]" data="YWI=" g.labels=map[k:v]`+"\n", out.String())
+	})
+
+	t.Run("fatal and panic drain the buffer first", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		logger := initAsyncLogger(t, out, AsyncConfig{})
+
+		logger.Info("before")
+		logger.Panic("panic")
+		logger.Fatal("fatal")
+
+		assert.Equal(t, "level=info msg=before\nlevel=panic msg=panic\nlevel=fatal msg=fatal\n", out.String())
+	})
+
+	t.Run("dpanic drains the buffer and panics in development", func(t *testing.T) {
+		t.Cleanup(ReplaceDevelopment(true))
+		out := newGatedWriter()
+		close(out.gate)
+		var panicked string
+		logger := NewAsyncLogger(NewLogfmtLogger(out, WithTimeKey(""), WithPanicHook(func(msg string) { panicked = msg })), AsyncConfig{})
+		t.Cleanup(func() { _ = logger.Close() })
+
+		logger.Info("before")
+		logger.DPanic("unexpected")
+
+		assert.Equal(t, "unexpected", panicked)
+		assert.Equal(t, "level=info msg=before\nlevel=dpanic msg=unexpected\n", out.String())
+	})
+
+	t.Run("flush interval syncs in the background", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		initAsyncLogger(t, out, AsyncConfig{FlushInterval: time.Millisecond})
+
+		require.Eventually(t, func() bool { return out.Syncs() > 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("close drains and later entries are written synchronously", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		logger := initAsyncLogger(t, out, AsyncConfig{})
+
+		logger.Info("queued")
+		require.NoError(t, logger.Close())
+		require.NoError(t, logger.Close())
+		logger.Info("after close")
+
+		assert.Equal(t, "level=info msg=queued\nlevel=info msg=\"after close\"\n", out.String())
+	})
+
+	t.Run("level override and controller reach the inner logger", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		logger := initAsyncLogger(t, out, AsyncConfig{})
+
+		NewLevelController(ErrorLevel).Apply(logger).Warn("hidden")
+		overrideLevel(NewLevelController(ErrorLevel).Apply(logger), WarnLevel).Warn("visible")
+		require.NoError(t, logger.Sync())
+
+		assert.Equal(t, "level=warn msg=visible\n", out.String())
+	})
+
+	t.Run("concurrent logging", func(t *testing.T) {
+		out := newGatedWriter()
+		close(out.gate)
+		logger := initAsyncLogger(t, out, AsyncConfig{BufferSize: 4, OnFull: DropOldest})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					logger.Info("entry")
+				}
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, logger.Sync())
+
+		written := uint64(bytes.Count([]byte(out.String()), []byte("\n")))
+		assert.Equal(t, uint64(400), written+logger.Dropped())
+	})
+}
@@ -2,9 +2,14 @@ package xlog
 
 import (
 	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/ruko1202/xlog/xfield"
 )
@@ -124,6 +129,94 @@ func BenchmarkAdvance_WithFields(b *testing.B) {
 	})
 }
 
+func BenchmarkJSONLogger(b *testing.B) {
+	loggers := map[string]Logger{
+		"zap adapter": NewZapAdapter(zap.New(zapcore.NewCore(
+			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			zapcore.AddSync(io.Discard),
+			zapcore.InfoLevel,
+		))),
+		"slog adapter": NewSlogAdapter(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		"json logger":  NewJSONLogger(io.Discard),
+	}
+
+	for name, logger := range loggers {
+		b.Run(name, func(b *testing.B) {
+			b.Run("message only", func(b *testing.B) {
+				withBenchedLogger(b, func() {
+					logger.Info("hello world")
+				})
+			})
+			b.Run("with fields", func(b *testing.B) {
+				withBenchedLogger(b, func() {
+					logger.Info("hello world",
+						xfield.String("string", "value"),
+						xfield.Int("int", 42),
+						xfield.Bool("bool", true),
+						xfield.Float64("float", 3.14),
+						xfield.Duration("duration", time.Second),
+					)
+				})
+			})
+			b.Run("reuse logger with fields", func(b *testing.B) {
+				logger := logger.Named("operation").With(
+					xfield.String("request_id", "req-123"),
+					xfield.String("user_id", "user-456"),
+				)
+				withBenchedLogger(b, func() {
+					logger.Info("hello world", xfield.Int("int", 42))
+				})
+			})
+			b.Run("disabled level", func(b *testing.B) {
+				withBenchedLogger(b, func() {
+					logger.Debug("hello world", xfield.Int("int", 42))
+				})
+			})
+		})
+	}
+}
+
+func BenchmarkHooks(b *testing.B) {
+	var count atomic.Int64
+	observe := func(*Entry) bool {
+		count.Add(1)
+		return true
+	}
+	addField := func(e *Entry) bool {
+		e.Fields = append(e.Fields, xfield.String("region", "eu"))
+		return true
+	}
+
+	loggers := map[string]Logger{
+		"without hooks":    NewJSONLogger(io.Discard),
+		"no hooks":         WithHooks(NewJSONLogger(io.Discard)),
+		"observing hook":   WithHooks(NewJSONLogger(io.Discard), observe),
+		"adding a field":   WithHooks(NewJSONLogger(io.Discard), addField),
+		"chain of 3 hooks": WithHooks(NewJSONLogger(io.Discard), observe, observe, observe),
+	}
+
+	for name, logger := range loggers {
+		b.Run(name, func(b *testing.B) {
+			withBenchedLogger(b, func() {
+				logger.Info("hello world", xfield.Int("int", 42))
+			})
+		})
+	}
+
+	b.Run("helper/no hooks", func(b *testing.B) {
+		ctx := ContextWithLogger(context.Background(), WithHooks(NewJSONLogger(io.Discard)))
+		withBenchedLogger(b, func() {
+			Info(ctx, "hello world", xfield.Int("int", 42))
+		})
+	})
+	b.Run("helper/observing hook", func(b *testing.B) {
+		ctx := ContextWithLogger(context.Background(), WithHooks(NewJSONLogger(io.Discard), observe))
+		withBenchedLogger(b, func() {
+			Info(ctx, "hello world", xfield.Int("int", 42))
+		})
+	})
+}
+
 func withBenchedLogger(b *testing.B, runBench func()) {
 	b.Helper()
 	b.ResetTimer()
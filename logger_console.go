@@ -0,0 +1,334 @@
+package xlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+const (
+	// consoleTimeFormat is the default time layout of the console logger.
+	consoleTimeFormat = "15:04:05.000"
+	// consoleMessageWidth is the width the message is padded to, so fields of consecutive entries line up.
+	consoleMessageWidth = 40
+	// consoleIDLength is the length trace_id and span_id are shortened to.
+	consoleIDLength = 8
+	consoleIndent   = "    "
+	// consoleBlockSeparator separates the inline part of pre-encoded fields from their indented lines.
+	// It can't appear in the inline part, since keys and values with control characters are quoted.
+	consoleBlockSeparator = 0x00
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorFaint   = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+)
+
+// NewConsoleLogger creates a Logger writing human-friendly lines to w, intended for local development.
+// Each entry is rendered as time, level, logger name, message and then key=value fields.
+// Multi-line values, such as errors with stack traces, and groups are written on the
+// following lines, indented. trace_id and span_id are shortened to their first 8 characters.
+//
+// Colors are enabled when w is a terminal and the NO_COLOR environment variable is not set,
+// use WithColor to force them. The time layout defaults to "15:04:05.000", use WithTimeFormat
+// to change it. An empty key set by WithTimeKey, WithLevelKey or WithNameKey omits that part.
+//
+// Example:
+//
+//	logger := xlog.NewConsoleLogger(os.Stderr, xlog.WithMinLevel(xlog.DebugLevel))
+//	logger.Named("http").Info("request processed", xfield.Int("status", 200))
+//	// 12:00:00.000 INFO  http  request processed                        status=200
+func NewConsoleLogger(w io.Writer, options ...LoggerOption) Logger {
+	opts := newLoggerOptions(append([]LoggerOption{WithTimeFormat(consoleTimeFormat)}, options...))
+
+	color := opts.color != nil && *opts.color
+	if opts.color == nil {
+		color = os.Getenv("NO_COLOR") == "" && isTerminal(w)
+	}
+
+	return newNativeLogger(w, &consoleEncoder{opts: opts, color: color}, opts)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleEncoder encodes entries as human-friendly lines.
+type consoleEncoder struct {
+	opts  *loggerOptions
+	color bool
+}
+
+func (e *consoleEncoder) appendFields(buf []byte, fields []xfield.Field) []byte {
+	inline, blocks := splitConsoleContext(buf)
+
+	out := make([]byte, 0, len(buf)+64)
+	out = append(out, inline...)
+	lines := append([]byte(nil), blocks...)
+	out, lines = e.appendFieldParts(out, lines, fields)
+	out = append(out, consoleBlockSeparator)
+	return append(out, lines...)
+}
+
+func (e *consoleEncoder) appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	inline, blocks := splitConsoleContext(context)
+
+	inlineBuf, linesBuf := getBuffer(), getBuffer()
+	defer putBuffer(inlineBuf)
+	defer putBuffer(linesBuf)
+	inlineBuf.b = append(inlineBuf.b, inline...)
+	linesBuf.b = append(linesBuf.b, blocks...)
+	inlineBuf.b, linesBuf.b = e.appendFieldParts(inlineBuf.b, linesBuf.b, fields)
+
+	if e.opts.timeKey != "" {
+		buf = e.appendColored(buf, colorFaint, rec.time.Format(e.opts.timeLayout))
+		buf = append(buf, ' ')
+	}
+	if e.opts.levelKey != "" {
+		label := strings.ToUpper(rec.level.String())
+		buf = e.appendColored(buf, levelColor(rec.level), label)
+		for i := len(label); i < 5; i++ {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, ' ')
+	}
+	if e.opts.nameKey != "" && rec.name != "" {
+		buf = e.appendColored(buf, colorBold, escapeConsoleText(rec.name))
+		buf = append(buf, ' ', ' ')
+	}
+	message := escapeConsoleText(rec.message)
+	buf = append(buf, message...)
+
+	if len(inlineBuf.b) > 0 {
+		for i := utf8.RuneCountInString(message); i < consoleMessageWidth; i++ {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, inlineBuf.b...)
+	}
+	buf = append(buf, '\n')
+	return append(buf, linesBuf.b...)
+}
+
+// appendFieldParts appends the single-line fields to inline as " key=value",
+// and multi-line values and groups to lines as indented lines.
+// The "<key>.stack" details of the errors whose stack is already printed are skipped.
+func (e *consoleEncoder) appendFieldParts(inline, lines []byte, fields []xfield.Field) (newInline, newLines []byte) {
+	var printedStacks []string
+	for i := range fields {
+		f := &fields[i]
+		if skipField(f) || slices.Contains(printedStacks, f.Key) {
+			continue
+		}
+
+		if f.Type == xfield.GroupType {
+			lines = e.appendGroup(lines, f, 1)
+			continue
+		}
+
+		value, detail := e.formatField(f)
+		if value != "" {
+			inline = append(inline, ' ')
+			inline = e.appendKeyValue(inline, f, value)
+		}
+		if detail != "" {
+			lines = e.appendDetail(lines, f.Key, detail, 1)
+			if f.Type == xfield.ErrorType {
+				printedStacks = append(printedStacks, f.Key+".stack")
+			}
+		}
+	}
+	return inline, lines
+}
+
+// appendGroup appends the group header and its fields one per line, indented by depth.
+func (e *consoleEncoder) appendGroup(buf []byte, group *xfield.Field, depth int) []byte {
+	buf = appendIndent(buf, depth)
+	buf = e.appendColored(buf, colorFaint, quoteConsoleValue(group.Key)+":")
+	buf = append(buf, '\n')
+
+	fields, ok := group.Interface.([]xfield.Field)
+	if !ok {
+		return buf
+	}
+	for i := range fields {
+		f := &fields[i]
+		if skipField(f) {
+			continue
+		}
+
+		if f.Type == xfield.GroupType {
+			buf = e.appendGroup(buf, f, depth+1)
+			continue
+		}
+
+		value, detail := e.formatField(f)
+		if value != "" {
+			buf = appendIndent(buf, depth+1)
+			buf = e.appendKeyValue(buf, f, value)
+			buf = append(buf, '\n')
+		}
+		if detail != "" {
+			buf = e.appendDetail(buf, f.Key, detail, depth+1)
+		}
+	}
+	return buf
+}
+
+// appendDetail appends a multi-line value under a "key:" header, indented by depth.
+func (e *consoleEncoder) appendDetail(buf []byte, key, detail string, depth int) []byte {
+	buf = appendIndent(buf, depth)
+	buf = e.appendColored(buf, colorFaint, quoteConsoleValue(key)+":")
+	buf = append(buf, '\n')
+
+	for _, line := range strings.Split(strings.TrimRight(detail, "\n"), "\n") {
+		buf = appendIndent(buf, depth+1)
+		buf = append(buf, escapeConsoleDetail(line)...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+func (e *consoleEncoder) appendKeyValue(buf []byte, f *xfield.Field, value string) []byte {
+	buf = e.appendColored(buf, colorFaint, quoteConsoleValue(f.Key)+"=")
+	if f.Type == xfield.ErrorType {
+		return e.appendColored(buf, colorRed, value)
+	}
+	return append(buf, value...)
+}
+
+// formatField returns the single-line value of the field, quoted if needed, and
+// its multi-line detail. Either of them may be empty.
+func (e *consoleEncoder) formatField(f *xfield.Field) (value, detail string) {
+	if f.Type == xfield.ErrorType {
+		err, _ := f.Interface.(error)
+		msg := err.Error()
+		if _, ok := err.(fmt.Formatter); ok {
+			if verbose := fmt.Sprintf("%+v", err); verbose != msg && strings.Contains(verbose, "\n") {
+				detail = verbose
+			}
+		}
+		if strings.Contains(msg, "\n") {
+			return "", msg
+		}
+		return quoteConsoleValue(msg), detail
+	}
+
+	value = f.FormatValue()
+	if strings.Contains(value, "\n") {
+		return "", value
+	}
+	if f.Type == xfield.StringType && (f.Key == "trace_id" || f.Key == "span_id") && len(value) > consoleIDLength {
+		value = value[:consoleIDLength]
+	}
+	return quoteConsoleValue(value), ""
+}
+
+func (e *consoleEncoder) appendColored(buf []byte, color, s string) []byte {
+	if !e.color {
+		return append(buf, s...)
+	}
+	buf = append(buf, color...)
+	buf = append(buf, s...)
+	return append(buf, colorReset...)
+}
+
+func levelColor(level Level) string {
+	switch {
+	case level <= DebugLevel:
+		return colorMagenta
+	case level == InfoLevel:
+		return colorBlue
+	case level == WarnLevel:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+// splitConsoleContext splits fields pre-encoded by appendFields into the inline part and the indented lines.
+func splitConsoleContext(context []byte) (inline, lines []byte) {
+	i := bytes.IndexByte(context, consoleBlockSeparator)
+	if i < 0 {
+		return context, nil
+	}
+	return context[:i], context[i+1:]
+}
+
+func appendIndent(buf []byte, depth int) []byte {
+	for i := 0; i < depth; i++ {
+		buf = append(buf, consoleIndent...)
+	}
+	return buf
+}
+
+// escapeConsoleText escapes the non-printable characters of the message or the logger name,
+// such as newlines, so they can't break the layout of the entry.
+func escapeConsoleText(s string) string {
+	return escapeConsole(s, isConsolePrint)
+}
+
+// escapeConsoleDetail escapes the non-printable characters of a line of a multi-line detail,
+// such as the ANSI escapes of an error message, keeping the tabs of the stacks.
+func escapeConsoleDetail(line string) string {
+	return escapeConsole(line, func(r rune) bool { return r == '\t' || isConsolePrint(r) })
+}
+
+func escapeConsole(s string, isPrint func(rune) bool) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { return r == utf8.RuneError || !isPrint(r) }) {
+		return s
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(s[i:], string(utf8.RuneError)):
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case isPrint(r):
+			b.WriteRune(r)
+		default:
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		}
+	}
+	return b.String()
+}
+
+func isConsolePrint(r rune) bool {
+	return r == ' ' || unicode.IsPrint(r)
+}
+
+// quoteConsoleValue quotes the key or the value if it is empty or contains spaces, '=', quotes
+// or non-printable characters, so the key=value pairs stay unambiguous.
+func quoteConsoleValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r == '=' || r == '"' || r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,250 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func initConsoleLogger(t *testing.T, options ...LoggerOption) (Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	options = append([]LoggerOption{
+		WithMinLevel(DebugLevel),
+		WithClock(func() time.Time { return testTime }),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	}, options...)
+
+	return NewConsoleLogger(buf, options...), buf
+}
+
+// stackError formats itself with a stack trace on %+v, like github.com/pkg/errors.
+type stackError struct {
+	msg string
+}
+
+func (e *stackError) Error() string {
+	return e.msg
+}
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = fmt.Fprintf(s, "%s\nmain.handler\n\t/app/main.go:42", e.msg)
+		return
+	}
+	_, _ = fmt.Fprint(s, e.msg)
+}
+
+func TestConsoleLogger(t *testing.T) {
+	t.Run("renders entry", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t)
+
+		logger.Named("http").Info("request processed", xfield.Int("status", 200), xfield.String("user", "john doe"))
+
+		assert.Equal(t,
+			"03:04:05.000 INFO  http  request processed"+strings.Repeat(" ", 23)+` status=200 user="john doe"`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("message without fields is not padded", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t)
+
+		logger.Warn("careful")
+		logger.Error("failed")
+		logger.Debug("details")
+
+		assert.Equal(t,
+			"03:04:05.000 WARN  careful\n03:04:05.000 ERROR failed\n03:04:05.000 DEBUG details\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("omitted parts", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""), WithNameKey(""))
+
+		logger.Named("http").Info("hello")
+
+		assert.Equal(t, "hello\n", buf.String())
+	})
+
+	t.Run("shortens trace and span ids", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Info("traced",
+			xfield.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+			xfield.String("span_id", "00f067aa0ba902b7"),
+		)
+
+		assert.Contains(t, buf.String(), " trace_id=4bf92f35 span_id=00f067aa\n")
+	})
+
+	t.Run("quotes values", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Info("quoted",
+			xfield.String("empty", ""),
+			xfield.String("eq", "a=b"),
+			xfield.String("ctl", "a\x01"),
+			xfield.String("plain", "ünï"),
+		)
+
+		assert.Contains(t, buf.String(), ` empty="" eq="a=b" ctl="a\x01" plain=ünï`+"\n")
+	})
+
+	t.Run("errors with stack traces span multiple lines", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Error("failed",
+			xfield.Error(&stackError{msg: "boom"}),
+			xfield.Error(errors.New("plain")),
+			xfield.NamedError("joined", errors.Join(errors.New("first"), errors.New("second"))),
+			xfield.Error(nil),
+		)
+
+		assert.Equal(t,
+			"failed"+strings.Repeat(" ", 34)+" error=boom error=plain\n"+
+				"    error:\n"+
+				"        boom\n"+
+				"        main.handler\n"+
+				"        \t/app/main.go:42\n"+
+				"    joined:\n"+
+				"        first\n"+
+				"        second\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("prints the stack of logged errors once", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		Error(ctx, "failed", xfield.Error(NewErr("boom")))
+
+		out := buf.String()
+		assert.Equal(t, 1, strings.Count(out, "github.com/ruko1202/xlog.TestConsoleLogger"), out)
+		assert.NotContains(t, out, "error.stack")
+		assert.Contains(t, out, " error=boom error.message=boom error.type=*errors.errorString\n")
+	})
+
+	t.Run("escapes keys and messages", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Named("a\nb").Info("line1\nline2\x00 \"q\"", xfield.String("k\x00ey", "v"), xfield.String("k\n", "v"))
+
+		assert.Equal(t,
+			`a\nb  line1\nline2\x00 "q"`+strings.Repeat(" ", 20)+` "k\x00ey"=v "k\n"=v`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("escapes control characters of details", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Error("failed", xfield.Error(&stackError{msg: "\x1b[2Jboom\r"}))
+
+		assert.Equal(t,
+			"failed"+strings.Repeat(" ", 34)+` error="\x1b[2Jboom\r"`+"\n"+
+				"    error:\n"+
+				`        \x1b[2Jboom\r`+"\n"+
+				"        main.handler\n"+
+				"        \t/app/main.go:42\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("indents groups", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		logger.Info("grouped",
+			xfield.String("key", "value"),
+			xfield.Group("http",
+				xfield.String("method", "GET"),
+				xfield.Group("response", xfield.Int("status", 200)),
+				xfield.String("body", "line1\nline2"),
+			),
+		)
+
+		assert.Equal(t,
+			"grouped"+strings.Repeat(" ", 33)+" key=value\n"+
+				"    http:\n"+
+				"        method=GET\n"+
+				"        response:\n"+
+				"            status=200\n"+
+				"        body:\n"+
+				"            line1\n"+
+				"            line2\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("with keeps context order", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		child := logger.
+			With(xfield.String("a", "1"), xfield.Group("g", xfield.Int("x", 1))).
+			With(xfield.String("b", "2"))
+		child.Info("hello", xfield.String("c", "3"))
+		logger.Info("parent")
+
+		assert.Equal(t,
+			"hello"+strings.Repeat(" ", 35)+" a=1 b=2 c=3\n"+
+				"    g:\n"+
+				"        x=1\n"+
+				"parent\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithColor(true), WithTimeKey(""))
+
+		logger.Error("failed", xfield.Error(errors.New("boom")))
+
+		assert.Equal(t,
+			colorRed+"ERROR"+colorReset+" failed"+strings.Repeat(" ", 34)+" "+
+				colorFaint+"error="+colorReset+colorRed+"boom"+colorReset+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("colors are disabled for non-terminals", func(t *testing.T) {
+		file, err := os.CreateTemp(t.TempDir(), "console")
+		require.NoError(t, err)
+		defer file.Close()
+
+		assert.False(t, isTerminal(file))
+		assert.False(t, isTerminal(&bytes.Buffer{}))
+
+		logger := NewConsoleLogger(file)
+		logger.Error("failed")
+		require.NoError(t, logger.Sync())
+
+		data, err := os.ReadFile(file.Name())
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "\x1b[")
+		assert.Contains(t, string(data), "ERROR failed\n")
+	})
+
+	t.Run("level override and controller", func(t *testing.T) {
+		logger, buf := initConsoleLogger(t, WithMinLevel(InfoLevel), WithTimeKey(""), WithLevelKey(""))
+
+		logger.Debug("hidden")
+		overrideLevel(logger, DebugLevel).Debug("visible")
+		NewLevelController(ErrorLevel).Apply(logger).Warn("hidden")
+
+		assert.Equal(t, "visible\n", buf.String())
+	})
+}
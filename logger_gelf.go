@@ -0,0 +1,214 @@
+package xlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// gelfFullMessageSeparator separates the additional fields pre-encoded by With
+// from the full_message details of their errors.
+const gelfFullMessageSeparator = 0x00
+
+// GELFConfig configures a GELF logger. Empty values are replaced with defaults.
+type GELFConfig struct {
+	// Host is the name of the host sending the messages. The default is os.Hostname.
+	Host string
+}
+
+// NewGELFLogger creates a Logger writing GELF 1.1 messages to w, usually a sink.GELFUDP
+// or a sink.Network with NullByte framing. Each entry is written with a single Write
+// and without a trailing newline, framing is left to the transport.
+//
+// The message is written as short_message and the level as a syslog severity
+// (see NewSyslogLogger). Fields become additional fields prefixed with '_', with
+// groups and objects flattened into dotted names, and the logger name is written
+// as "_logger". When there are error fields, full_message holds the message followed
+// by each error, its chain of causes and its stack trace if it has one.
+// The time, level and message keys of the options are ignored.
+//
+// Example:
+//
+//	conn, err := sink.NewGELFUDP("graylog.internal:12201", sink.WithGzip())
+//	if err != nil {
+//		return err
+//	}
+//	logger := xlog.NewGELFLogger(conn, xlog.GELFConfig{})
+//	logger.Info("request processed", xfield.Int("status", 200))
+//	// {"version":"1.1","host":"web-1","short_message":"request processed","timestamp":1704067200.000000,"level":6,"_status":200}
+func NewGELFLogger(w io.Writer, cfg GELFConfig, options ...LoggerOption) Logger {
+	opts := newLoggerOptions(options)
+
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+
+	return newNativeLogger(w, &gelfEncoder{opts: opts, host: cfg.Host}, opts)
+}
+
+// gelfEncoder encodes entries as GELF messages.
+type gelfEncoder struct {
+	opts *loggerOptions
+	host string
+}
+
+func (e *gelfEncoder) appendFields(buf []byte, fields []xfield.Field) []byte {
+	additional, details := splitGELFContext(buf)
+
+	out := make([]byte, 0, len(buf)+64)
+	out = append(out, additional...)
+	out = e.appendAdditional(out, "", fields)
+	out = append(out, gelfFullMessageSeparator)
+	out = append(out, details...)
+	return appendGELFDetails(out, fields)
+}
+
+func (e *gelfEncoder) appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	additional, details := splitGELFContext(context)
+
+	buf = append(buf, `{"version":"1.1","host":`...)
+	buf = appendJSONString(buf, e.host)
+
+	message := rec.message
+	if message == "" {
+		message = "-" // short_message is mandatory and can't be empty
+	}
+	buf = append(buf, `,"short_message":`...)
+	buf = appendJSONString(buf, message)
+
+	detailsBuf := getBuffer()
+	defer putBuffer(detailsBuf)
+	detailsBuf.b = append(detailsBuf.b, details...)
+	detailsBuf.b = appendGELFDetails(detailsBuf.b, fields)
+	if len(detailsBuf.b) > 0 {
+		buf = append(buf, `,"full_message":`...)
+		buf = appendJSONString(buf, message+"\n"+string(bytes.TrimSuffix(detailsBuf.b, []byte{'\n'})))
+	}
+
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendInt(buf, rec.time.Unix(), 10)
+	buf = append(buf, '.')
+	micros := strconv.Itoa(rec.time.Nanosecond() / 1000)
+	buf = append(buf, "000000"[len(micros):]...)
+	buf = append(buf, micros...)
+
+	buf = append(buf, `,"level":`...)
+	buf = strconv.AppendInt(buf, int64(syslogSeverity(rec.level)), 10)
+
+	if e.opts.nameKey != "" && rec.name != "" {
+		buf = appendGELFPair(buf, e.opts.nameKey, rec.name)
+	}
+	buf = append(buf, additional...)
+	buf = e.appendAdditional(buf, "", fields)
+	return append(buf, '}')
+}
+
+// appendAdditional appends the fields as additional fields, with groups flattened into dotted names.
+// GELF only allows strings and numbers, so other values are written as strings.
+func (e *gelfEncoder) appendAdditional(buf []byte, prefix string, fields []xfield.Field) []byte {
+	for i := range fields {
+		f := &fields[i]
+		switch f.Type {
+		case xfield.Int64Type, xfield.Uint64Type:
+			buf = appendGELFKey(buf, prefix+f.Key)
+			buf = append(buf, formatFlatValue(f, e.opts.timeLayout)...)
+		case xfield.Float64Type:
+			buf = appendGELFKey(buf, prefix+f.Key)
+			buf = appendJSONFloat(buf, f.Float, 64)
+		case xfield.GroupType:
+			if nested, ok := f.Interface.([]xfield.Field); ok {
+				buf = e.appendAdditional(buf, prefix+f.Key+".", nested)
+				continue
+			}
+			buf = appendFlatField(buf, prefix, f, e.opts.timeLayout, appendGELFPair)
+		default:
+			buf = appendFlatField(buf, prefix, f, e.opts.timeLayout, appendGELFPair)
+		}
+	}
+	return buf
+}
+
+// appendGELFKey appends `,"_key":`. Characters not allowed in field names are replaced with '_',
+// and the reserved "id" becomes "id_".
+func appendGELFKey(buf []byte, key string) []byte {
+	if key == "id" || key == "" {
+		key += "_"
+	}
+
+	buf = append(buf, `,"_`...)
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '.' && c != '-' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return append(buf, `":`...)
+}
+
+func appendGELFPair(buf []byte, key, value string) []byte {
+	buf = appendGELFKey(buf, key)
+	return appendJSONString(buf, value)
+}
+
+// appendGELFDetails appends "key: error" for each error field, followed by the errors it wraps,
+// including the trees of errors.Join, and its stack trace, if the error formats one with %+v.
+func appendGELFDetails(buf []byte, fields []xfield.Field) []byte {
+	for i := range fields {
+		f := &fields[i]
+		switch f.Type {
+		case xfield.ErrorType:
+			err, ok := f.Interface.(error)
+			if !ok || err == nil {
+				continue
+			}
+			buf = appendGELFError(buf, f.Key, err)
+		case xfield.GroupType:
+			if nested, ok := f.Interface.([]xfield.Field); ok {
+				buf = appendGELFDetails(buf, nested)
+			}
+		}
+	}
+	return buf
+}
+
+func appendGELFError(buf []byte, key string, err error) []byte {
+	msg := err.Error()
+	buf = append(buf, key...)
+	buf = append(buf, ": "...)
+	buf = append(buf, msg...)
+	buf = append(buf, '\n')
+
+	chain := errorChain(err)
+	if len(chain) > 0 && chain[0] == msg {
+		chain = chain[1:]
+	}
+	for _, cause := range chain {
+		buf = append(buf, "caused by: "...)
+		buf = append(buf, cause...)
+		buf = append(buf, '\n')
+	}
+
+	if _, ok := err.(fmt.Formatter); ok {
+		if verbose := fmt.Sprintf("%+v", err); verbose != msg && strings.Contains(verbose, "\n") {
+			buf = append(buf, verbose...)
+			buf = append(buf, '\n')
+		}
+	}
+	return buf
+}
+
+// splitGELFContext splits the context pre-encoded by appendFields into the additional fields
+// and the full_message details. JSON never contains a raw zero byte, so the first one is the separator.
+func splitGELFContext(context []byte) (additional, details []byte) {
+	i := bytes.IndexByte(context, gelfFullMessageSeparator)
+	if i < 0 {
+		return context, nil
+	}
+	return context[:i], context[i+1:]
+}
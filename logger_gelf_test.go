@@ -0,0 +1,207 @@
+package xlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/sink"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func initGELFLogger(t *testing.T, options ...LoggerOption) (Logger, *recordingWriter) {
+	t.Helper()
+
+	out := &recordingWriter{}
+	options = append([]LoggerOption{
+		WithMinLevel(DebugLevel),
+		WithClock(func() time.Time { return testTime }),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	}, options...)
+
+	return NewGELFLogger(out, GELFConfig{Host: "host"}, options...), out
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decompressed)
+}
+
+func TestGELFLogger(t *testing.T) {
+	t.Run("encodes message and additional fields", func(t *testing.T) {
+		logger, out := initGELFLogger(t)
+
+		logger.Named("http").With(xfield.String("service", "api")).Info("request processed",
+			xfield.Int("status", 200),
+			xfield.Uint64("bytes", 1<<63),
+			xfield.Float64("ratio", 0.5),
+			xfield.Bool("cached", true),
+			xfield.Duration("took", time.Second),
+			xfield.Group("user", xfield.Int("id", 7), xfield.Group("geo", xfield.String("country", "NL"))),
+			xfield.String("id", "reserved"),
+			xfield.String("bad key!", "v"),
+			xfield.Error(nil),
+		)
+
+		require.Len(t, out.messages, 1)
+		assert.Equal(t,
+			`{"version":"1.1","host":"host","short_message":"request processed","timestamp":1704164645.000006,"level":6,`+
+				`"_logger":"http","_service":"api","_status":200,"_bytes":9223372036854775808,"_ratio":0.5,"_cached":"true","_took":"1s",`+
+				`"_user.id":7,"_user.geo.country":"NL","_id_":"reserved","_bad_key_":"v"}`,
+			out.messages[0],
+		)
+		require.True(t, json.Valid([]byte(out.messages[0])))
+	})
+
+	t.Run("maps levels to syslog severities", func(t *testing.T) {
+		logger, out := initGELFLogger(t)
+
+		logger.Debug("m")
+		logger.Info("m")
+		logger.Warn("m")
+		logger.Error("m")
+		logger.Panic("m")
+		logger.Fatal("m")
+
+		var levels []int
+		for _, msg := range out.messages {
+			var decoded struct{ Level int }
+			require.NoError(t, json.Unmarshal([]byte(msg), &decoded))
+			levels = append(levels, decoded.Level)
+		}
+		assert.Equal(t, []int{7, 6, 4, 3, 1, 0}, levels)
+	})
+
+	t.Run("full message holds error chains and stacks", func(t *testing.T) {
+		logger, out := initGELFLogger(t)
+
+		cause := errors.New("connection refused")
+		logger.With(xfield.NamedError("setup", &stackError{msg: "boom"})).Error("query failed",
+			xfield.Error(fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", cause))),
+			xfield.NamedError("cleanup", errors.Join(errors.New("close conn"), WrapErr(errors.New("release lock")))),
+		)
+
+		require.Len(t, out.messages, 1)
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out.messages[0]), &decoded))
+		assert.Equal(t, "query failed", decoded["short_message"])
+		assert.Equal(t,
+			"query failed\n"+
+				"setup: boom\n"+
+				"boom\n"+
+				"main.handler\n"+
+				"\t/app/main.go:42\n"+
+				"error: query users: dial db: connection refused\n"+
+				"caused by: dial db: connection refused\n"+
+				"caused by: connection refused\n"+
+				"cleanup: close conn\nrelease lock\n"+
+				"caused by: close conn\n"+
+				"caused by: release lock",
+			decoded["full_message"],
+		)
+		assert.Equal(t, "boom", decoded["_setup"])
+		assert.Equal(t, "query users: dial db: connection refused", decoded["_error"])
+	})
+
+	t.Run("empty message", func(t *testing.T) {
+		logger, out := initGELFLogger(t)
+
+		logger.Info("")
+
+		assert.Equal(t, []string{`{"version":"1.1","host":"host","short_message":"-","timestamp":1704164645.000006,"level":6}`}, out.messages)
+	})
+
+	t.Run("defaults host to the hostname", func(t *testing.T) {
+		out := &recordingWriter{}
+		NewGELFLogger(out, GELFConfig{}).Info("m")
+
+		require.Len(t, out.messages, 1)
+		var decoded struct{ Host string }
+		require.NoError(t, json.Unmarshal([]byte(out.messages[0]), &decoded))
+		assert.NotEmpty(t, decoded.Host)
+	})
+}
+
+func TestGELFLoggerOverNetwork(t *testing.T) {
+	t.Run("udp with chunking and compression", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := sink.NewGELFUDP(conn.LocalAddr().String(), sink.WithChunkSize(64), sink.WithGzip())
+		require.NoError(t, err)
+		defer w.Close()
+
+		logger := NewGELFLogger(w, GELFConfig{Host: "host"}, WithClock(func() time.Time { return testTime }))
+		// random data doesn't compress well, so the message needs several chunks
+		data := make([]byte, 300)
+		_, _ = rand.NewChaCha8([32]byte{}).Read(data)
+		logger.Info("large", xfield.Binary("data", data))
+
+		var payload []byte
+		buf := make([]byte, 128)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		count := 1
+		for seq := 0; seq < count; seq++ {
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			require.Equal(t, []byte{0x1e, 0x0f}, buf[:2])
+			require.Equal(t, byte(seq), buf[10])
+			count = int(buf[11])
+			payload = append(payload, buf[12:n]...)
+		}
+
+		assert.Greater(t, count, 1)
+		message := gunzip(t, payload)
+		assert.True(t, strings.HasPrefix(message, `{"version":"1.1","host":"host","short_message":"large"`), message)
+		assert.True(t, json.Valid([]byte(message)))
+	})
+
+	t.Run("tcp with null byte framing", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		received := make(chan string, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			data, _ := io.ReadAll(conn)
+			received <- string(data)
+		}()
+
+		w, err := sink.NewNetwork("tcp", ln.Addr().String(), sink.WithFraming(sink.NullByte))
+		require.NoError(t, err)
+
+		logger := NewGELFLogger(w, GELFConfig{Host: "host"}, WithClock(func() time.Time { return testTime }))
+		logger.Info("first")
+		logger.Warn("second")
+		require.NoError(t, w.Close())
+
+		assert.Equal(t,
+			`{"version":"1.1","host":"host","short_message":"first","timestamp":1704164645.000006,"level":6}`+"\x00"+
+				`{"version":"1.1","host":"host","short_message":"second","timestamp":1704164645.000006,"level":4}`+"\x00",
+			<-received,
+		)
+	})
+}
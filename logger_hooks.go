@@ -0,0 +1,248 @@
+package xlog
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// Entry is a log entry passed through the hooks of a logger created by WithHooks.
+type Entry struct {
+	// Level is the level the entry is written at. Hooks can change it, see Hook.
+	Level Level
+	// Time is when the entry was logged. Changing it has no effect on the output,
+	// the inner logger uses its own clock.
+	Time time.Time
+	// LoggerName is the name built by Named, including the names given to the inner logger before
+	// WithHooks when it keeps them. Changing it has no effect on the output.
+	LoggerName string
+	// Message is the log message.
+	Message string
+	// Fields are the fields passed at the call site. The fields added by With are already attached
+	// to the inner logger and aren't included. The slice is a copy, hooks can modify it in place.
+	Fields []xfield.Field
+	// Ctx is the context passed to the package-level helpers (xlog.Info, ...),
+	// or context.Background() when the logger is called directly.
+	Ctx context.Context
+}
+
+// Hook processes an entry before it is written and returns false to drop it.
+// Hooks only see the entries at levels enabled by the inner logger, see LevelEnabler.
+// Hooks can modify the entry, including its level: entries are written at the level
+// left by the last hook. Entries logged with Panic and Fatal can't be dropped and keep
+// their level, so the program still panics or exits; other entries raised above
+// DPanicLevel are written at ErrorLevel.
+type Hook func(entry *Entry) bool
+
+// contextLogger is implemented by loggers that use the context of the package-level helpers.
+type contextLogger interface {
+	logContext(ctx context.Context, level Level, msg string, fields []xfield.Field)
+}
+
+// HookedLogger is a Logger running hooks on every entry before writing it to the inner logger.
+type HookedLogger struct {
+	inner Logger
+	hooks []Hook
+	name  string
+}
+
+// WithHooks returns a Logger running the hooks in order on every entry before writing it to inner.
+// A hook returning false drops the entry and the following hooks don't see it.
+// The hooks are kept by the loggers created by With and Named. If inner already has hooks,
+// the new ones run after them. Without hooks, inner is returned unchanged.
+//
+// Example:
+//
+//	logger := xlog.WithHooks(xlog.NewJSONLogger(os.Stdout),
+//		func(e *xlog.Entry) bool {
+//			e.Fields = append(e.Fields, xfield.String("region", region))
+//			return true
+//		},
+//		func(e *xlog.Entry) bool {
+//			return e.Message != "health check"
+//		},
+//	)
+func WithHooks(inner Logger, hooks ...Hook) Logger {
+	if len(hooks) == 0 {
+		return inner
+	}
+
+	if hooked, ok := inner.(*HookedLogger); ok {
+		return &HookedLogger{
+			inner: hooked.inner,
+			hooks: slices.Concat(hooked.hooks, hooks),
+			name:  hooked.name,
+		}
+	}
+	return &HookedLogger{inner: inner, hooks: slices.Clone(hooks), name: loggerNameOf(inner)}
+}
+
+// Debug logs a debug-level message.
+func (h *HookedLogger) Debug(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), DebugLevel, msg, fields)
+}
+
+// Info logs an info-level message.
+func (h *HookedLogger) Info(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), InfoLevel, msg, fields)
+}
+
+// Warn logs a warning-level message.
+func (h *HookedLogger) Warn(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), WarnLevel, msg, fields)
+}
+
+// Error logs an error-level message.
+func (h *HookedLogger) Error(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), ErrorLevel, msg, fields)
+}
+
+// Fatal logs a fatal-level message and terminates the program.
+func (h *HookedLogger) Fatal(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), FatalLevel, msg, fields)
+}
+
+// DPanic logs a dpanic-level message and, in development, panics.
+func (h *HookedLogger) DPanic(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), DPanicLevel, msg, fields)
+}
+
+// Panic logs a panic-level message and panics.
+func (h *HookedLogger) Panic(msg string, fields ...xfield.Field) {
+	h.logContext(context.Background(), PanicLevel, msg, fields)
+}
+
+// With creates a child logger with additional fields, keeping the hooks.
+func (h *HookedLogger) With(fields ...xfield.Field) Logger {
+	return &HookedLogger{inner: h.inner.With(fields...), hooks: h.hooks, name: h.name}
+}
+
+// Named creates a named child logger, keeping the hooks.
+func (h *HookedLogger) Named(name string) Logger {
+	return &HookedLogger{inner: h.inner.Named(name), hooks: h.hooks, name: joinLoggerName(h.name, name)}
+}
+
+// Sync syncs the inner logger.
+func (h *HookedLogger) Sync() error {
+	return h.inner.Sync()
+}
+
+// WithLevelOverride returns a child logger whose inner logger uses the given minimum level.
+func (h *HookedLogger) WithLevelOverride(level Level) Logger {
+	return &HookedLogger{inner: overrideLevel(h.inner, level), hooks: h.hooks, name: h.name}
+}
+
+// WithLevelController returns a child logger whose inner logger levels are decided by the controller.
+func (h *HookedLogger) WithLevelController(controller *LevelController) Logger {
+	return &HookedLogger{inner: controller.Apply(h.inner), hooks: h.hooks, name: h.name}
+}
+
+// Enabled reports whether the inner logger writes entries at the given level.
+func (h *HookedLogger) Enabled(level Level) bool {
+	return levelEnabled(h.inner, level)
+}
+
+func (h *HookedLogger) loggerName() string {
+	return h.name
+}
+
+// Unwrap returns the inner logger.
+func (h *HookedLogger) Unwrap() Logger {
+	return h.inner
+}
+
+func (h *HookedLogger) logContext(ctx context.Context, level Level, msg string, fields []xfield.Field) {
+	h.logEntry(ctx, time.Time{}, level, msg, fields)
+}
+
+func (h *HookedLogger) now() time.Time {
+	if tl, ok := h.inner.(timedLogger); ok {
+		return tl.now()
+	}
+	return time.Now()
+}
+
+func (h *HookedLogger) logAt(t time.Time, level Level, msg string, fields []xfield.Field) {
+	h.logEntry(context.Background(), t, level, msg, fields)
+}
+
+// logEntry runs the hooks and writes the entry to the inner logger. t is the time the entry was
+// logged at, passed on to the inner logger when it is a timedLogger, or zero for the current time.
+func (h *HookedLogger) logEntry(ctx context.Context, t time.Time, level Level, msg string, fields []xfield.Field) {
+	terminal := level >= PanicLevel
+	if !terminal && !levelEnabled(h.inner, level) {
+		return
+	}
+
+	entry := Entry{
+		Level:      level,
+		Time:       t,
+		LoggerName: h.name,
+		Message:    msg,
+		Fields:     slices.Clone(fields),
+		Ctx:        ctx,
+	}
+
+	if t.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	for _, hook := range h.hooks {
+		if !hook(&entry) && !terminal {
+			return
+		}
+	}
+
+	switch {
+	case terminal:
+		entry.Level = level
+	case entry.Level > DPanicLevel:
+		entry.Level = ErrorLevel
+	}
+	if tl, ok := h.inner.(timedLogger); ok && !t.IsZero() && entry.Level <= ErrorLevel {
+		tl.logAt(entry.Time, entry.Level, entry.Message, entry.Fields)
+		return
+	}
+	logWithContext(ctx, h.inner, entry.Level, entry.Message, entry.Fields)
+}
+
+// logWithContext writes the entry with the method matching the level,
+// passing the context to loggers that use it.
+func logWithContext(ctx context.Context, logger Logger, level Level, msg string, fields []xfield.Field) {
+	if cl, ok := logger.(contextLogger); ok {
+		cl.logContext(ctx, level, msg, fields)
+		return
+	}
+
+	switch {
+	case level <= DebugLevel:
+		logger.Debug(msg, fields...)
+	case level == InfoLevel:
+		logger.Info(msg, fields...)
+	case level == WarnLevel:
+		logger.Warn(msg, fields...)
+	case level == DPanicLevel:
+		dpanic(logger, msg, fields)
+	case level < DPanicLevel:
+		logger.Error(msg, fields...)
+	case level == PanicLevel:
+		logger.Panic(msg, fields...)
+	default:
+		logger.Fatal(msg, fields...)
+	}
+}
+
+// dpanic logs the message with the DPanic method of the logger, or at ErrorLevel
+// followed by a panic in development if the logger doesn't implement DPanicLogger.
+func dpanic(logger Logger, msg string, fields []xfield.Field) {
+	if dl, ok := logger.(DPanicLogger); ok {
+		dl.DPanic(msg, fields...)
+		return
+	}
+	logger.Error(msg, fields...)
+	if IsDevelopment() {
+		panic(msg)
+	}
+}
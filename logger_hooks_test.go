@@ -0,0 +1,203 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+type hooksCtxKey struct{}
+
+func initHookedLogger(t *testing.T, hooks ...Hook) (Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	inner := NewLogfmtLogger(buf,
+		WithMinLevel(DebugLevel),
+		WithTimeKey(""),
+		WithFatalHook(func() { buf.WriteString("exit\n") }),
+		WithPanicHook(func(string) { buf.WriteString("panic\n") }),
+	)
+	return WithHooks(inner, hooks...), buf
+}
+
+func TestWithHooks(t *testing.T) {
+	t.Run("without hooks returns the inner logger", func(t *testing.T) {
+		inner := NewNoopLogger()
+		assert.Same(t, inner, WithHooks(inner))
+	})
+
+	t.Run("modifies entries", func(t *testing.T) {
+		logger, buf := initHookedLogger(t, func(e *Entry) bool {
+			e.Message = strings.ToUpper(e.Message)
+			e.Fields = append(e.Fields, xfield.String("region", "eu"))
+			for i := range e.Fields {
+				if e.Fields[i].Key == "password" {
+					e.Fields[i] = xfield.String("password", "***")
+				}
+			}
+			return true
+		})
+
+		fields := []xfield.Field{xfield.String("password", "secret")}
+		logger.Info("login", fields...)
+
+		assert.Equal(t, "level=info msg=LOGIN password=*** region=eu\n", buf.String())
+		assert.Equal(t, "secret", fields[0].String, "the caller's fields are not modified")
+	})
+
+	t.Run("drops entries and stops the chain", func(t *testing.T) {
+		var seen []string
+		logger, buf := initHookedLogger(t,
+			func(e *Entry) bool {
+				seen = append(seen, "first:"+e.Message)
+				return e.Message != "health check"
+			},
+			func(e *Entry) bool {
+				seen = append(seen, "second:"+e.Message)
+				return true
+			},
+		)
+
+		logger.Info("health check")
+		logger.Info("request")
+
+		assert.Equal(t, "level=info msg=request\n", buf.String())
+		assert.Equal(t, []string{"first:health check", "first:request", "second:request"}, seen)
+	})
+
+	t.Run("runs hooks in registration order across WithHooks calls", func(t *testing.T) {
+		var order []int
+		hook := func(n int) Hook {
+			return func(*Entry) bool {
+				order = append(order, n)
+				return true
+			}
+		}
+		logger, _ := initHookedLogger(t, hook(1), hook(2))
+		logger = WithHooks(logger, hook(3))
+
+		logger.Info("m")
+
+		assert.Equal(t, []int{1, 2, 3}, order)
+		_, nested := logger.(*HookedLogger).Unwrap().(*HookedLogger)
+		assert.False(t, nested)
+	})
+
+	t.Run("keeps hooks across With and Named", func(t *testing.T) {
+		var names []string
+		logger, buf := initHookedLogger(t, func(e *Entry) bool {
+			names = append(names, e.LoggerName)
+			return true
+		})
+
+		logger.Named("http").With(xfield.Int("n", 1)).Named("handler").Info("m", xfield.Int("k", 2))
+
+		assert.Equal(t, []string{"http.handler"}, names)
+		assert.Equal(t, "level=info logger=http.handler msg=m n=1 k=2\n", buf.String())
+	})
+
+	t.Run("names include the names of the inner logger", func(t *testing.T) {
+		var names []string
+		hook := func(e *Entry) bool {
+			names = append(names, e.LoggerName)
+			return true
+		}
+		inner := NewLogfmtLogger(&bytes.Buffer{}).Named("app")
+
+		async := NewAsyncLogger(inner.Named("db"), AsyncConfig{})
+		defer async.Close()
+
+		WithHooks(inner, hook).Named("http").Info("m")
+		WithHooks(async, hook).Info("m")
+
+		assert.Equal(t, []string{"app.http", "app.db"}, names)
+	})
+
+	t.Run("passes the context of the package-level helpers", func(t *testing.T) {
+		var values []any
+		logger, _ := initHookedLogger(t, func(e *Entry) bool {
+			values = append(values, e.Ctx.Value(hooksCtxKey{}))
+			return true
+		})
+
+		ctx := ContextWithLogger(context.WithValue(context.Background(), hooksCtxKey{}, "request"), logger)
+		Info(ctx, "through helper")
+		logger.Info("direct")
+
+		assert.Equal(t, []any{"request", nil}, values)
+	})
+
+	t.Run("changes levels", func(t *testing.T) {
+		logger, buf := initHookedLogger(t, func(e *Entry) bool {
+			switch e.Message {
+			case "lower":
+				e.Level = DebugLevel
+			case "raise":
+				e.Level = FatalLevel
+			case "terminal":
+				e.Level = InfoLevel
+				return false
+			}
+			return true
+		})
+
+		logger.Warn("lower")
+		logger.Warn("raise")
+		logger.Panic("terminal")
+		logger.Fatal("terminal")
+
+		assert.Equal(t,
+			"level=debug msg=lower\nlevel=error msg=raise\nlevel=panic msg=terminal\npanic\nlevel=fatal msg=terminal\nexit\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("dpanic entries can be dropped and set by hooks", func(t *testing.T) {
+		t.Cleanup(ReplaceDevelopment(true))
+		logger, buf := initHookedLogger(t, func(e *Entry) bool {
+			if e.Message == "escalate" {
+				e.Level = DPanicLevel
+			}
+			return e.Message != "dropped"
+		})
+
+		logger.(DPanicLogger).DPanic("dropped")
+		logger.Error("escalate")
+
+		assert.Equal(t, "level=dpanic msg=escalate\npanic\n", buf.String())
+	})
+
+	t.Run("level override reaches the inner logger", func(t *testing.T) {
+		var count int
+		logger, buf := initHookedLogger(t, func(*Entry) bool {
+			count++
+			return true
+		})
+
+		overrideLevel(logger, WarnLevel).Info("hidden")
+		NewLevelController(ErrorLevel).Apply(logger).Error("visible")
+
+		assert.Equal(t, "level=error msg=visible\n", buf.String())
+		assert.Equal(t, 1, count, "hooks don't see the entries the inner logger discards")
+	})
+
+	t.Run("entry time", func(t *testing.T) {
+		var entry Entry
+		logger, _ := initHookedLogger(t, func(e *Entry) bool {
+			entry = *e
+			return true
+		})
+
+		logger.Info("m")
+
+		require.False(t, entry.Time.IsZero())
+		assert.Equal(t, InfoLevel, entry.Level)
+	})
+}
@@ -1,6 +1,10 @@
 package xlog
 
-import "github.com/ruko1202/xlog/xfield"
+import (
+	"time"
+
+	"github.com/ruko1202/xlog/xfield"
+)
 
 // Logger is the interface that wraps the basic logging methods.
 // This interface allows xlog to work with any logging backend (zap, slog, logrus, etc).
@@ -35,3 +39,63 @@ type Logger interface {
 	// Applications should call Sync before exiting to ensure all logs are written.
 	Sync() error
 }
+
+// LevelOverrider is implemented by loggers that can change their minimum level
+// independently of the backend configuration. WithLevel relies on it to enable
+// entries below the level of the underlying logger.
+type LevelOverrider interface {
+	// WithLevelOverride returns a child logger with the given minimum level.
+	WithLevelOverride(level Level) Logger
+}
+
+// LevelControllable is implemented by loggers whose levels can be driven by a LevelController.
+type LevelControllable interface {
+	// WithLevelController returns a child logger whose levels are decided by the controller.
+	WithLevelController(controller *LevelController) Logger
+}
+
+// LevelEnabler is implemented by loggers that can tell whether they write entries at a level,
+// so wrappers such as AsyncLogger skip the entries the inner logger would discard.
+type LevelEnabler interface {
+	// Enabled reports whether entries at the given level are written.
+	Enabled(level Level) bool
+}
+
+// levelEnabled reports whether the logger writes entries at the level,
+// assuming it does if it doesn't implement LevelEnabler.
+func levelEnabled(logger Logger, level Level) bool {
+	if le, ok := logger.(LevelEnabler); ok {
+		return le.Enabled(level)
+	}
+	return true
+}
+
+// namedLogger is implemented by loggers that know the name built by Named.
+type namedLogger interface {
+	loggerName() string
+}
+
+// loggerNameOf returns the name built by Named on the logger, or "" if the logger doesn't keep it.
+func loggerNameOf(logger Logger) string {
+	if nl, ok := logger.(namedLogger); ok {
+		return nl.loggerName()
+	}
+	return ""
+}
+
+// timedLogger is implemented by loggers that can write an entry with the time it was logged at,
+// so an AsyncLogger keeps the time of the call rather than the time of the write.
+type timedLogger interface {
+	// now returns the current time by the clock of the logger.
+	now() time.Time
+	// logAt writes an entry at a level up to ErrorLevel with the given time.
+	logAt(t time.Time, level Level, msg string, fields []xfield.Field)
+}
+
+// DPanicLogger is implemented by loggers with a development panic level.
+// DPanic logs a message at DPanicLevel and panics in development only,
+// so the loggers not implementing it log DPanic entries at ErrorLevel.
+type DPanicLogger interface {
+	// DPanic logs a dpanic-level message with structured fields and panics in development.
+	DPanic(msg string, fields ...xfield.Field)
+}
@@ -0,0 +1,267 @@
+package xlog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// NewJSONLogger creates a Logger writing one JSON object per line to w.
+// Fields are encoded directly from xfield.Field into a pooled buffer, without
+// the intermediate conversion done by the zap and slog adapters.
+//
+// Example:
+//
+//	logger := xlog.NewJSONLogger(os.Stdout, xlog.WithMinLevel(xlog.DebugLevel))
+//	logger.Info("request processed", xfield.Int("status", 200))
+//	// {"time":"2024-01-01T00:00:00Z","level":"info","msg":"request processed","status":200}
+func NewJSONLogger(w io.Writer, options ...LoggerOption) Logger {
+	opts := newLoggerOptions(options)
+	return newNativeLogger(w, &jsonEncoder{opts: opts}, opts)
+}
+
+// jsonEncoder encodes entries as JSON objects.
+type jsonEncoder struct {
+	opts *loggerOptions
+}
+
+func (e *jsonEncoder) appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	buf = append(buf, '{')
+	if e.opts.timeKey != "" {
+		buf = appendJSONKey(buf, e.opts.timeKey)
+		buf = appendJSONTime(buf, rec.time, e.opts.timeLayout)
+	}
+	if e.opts.levelKey != "" {
+		buf = appendJSONKey(buf, e.opts.levelKey)
+		buf = appendJSONString(buf, rec.level.String())
+	}
+	if e.opts.nameKey != "" && rec.name != "" {
+		buf = appendJSONKey(buf, e.opts.nameKey)
+		buf = appendJSONString(buf, rec.name)
+	}
+	if e.opts.messageKey != "" {
+		buf = appendJSONKey(buf, e.opts.messageKey)
+		buf = appendJSONString(buf, rec.message)
+	}
+	if len(context) > 0 {
+		if buf[len(buf)-1] != '{' {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, context...)
+	}
+	buf = e.appendFields(buf, fields)
+	return append(buf, '}', '\n')
+}
+
+func (e *jsonEncoder) appendFields(buf []byte, fields []xfield.Field) []byte {
+	for i := range fields {
+		if skipField(&fields[i]) {
+			continue
+		}
+		buf = appendJSONKey(buf, fields[i].Key)
+		buf = e.appendValue(buf, &fields[i])
+	}
+	return buf
+}
+
+// appendValue appends the JSON value of the field.
+//
+//nolint:gocyclo // switch on field types requires many cases
+func (e *jsonEncoder) appendValue(buf []byte, f *xfield.Field) []byte {
+	switch f.Type {
+	case xfield.StringType:
+		return appendJSONString(buf, f.String)
+	case xfield.Int64Type:
+		return strconv.AppendInt(buf, f.Integer, 10)
+	case xfield.Uint64Type:
+		// #nosec G115 - safe conversion as Uint64 values are stored as int64
+		return strconv.AppendUint(buf, uint64(f.Integer), 10)
+	case xfield.Float64Type:
+		return appendJSONFloat(buf, f.Float, 64)
+	case xfield.BoolType:
+		return strconv.AppendBool(buf, f.Integer == 1)
+	case xfield.TimeType:
+		if t, ok := f.Interface.(time.Time); ok {
+			return appendJSONTime(buf, t, e.opts.timeLayout)
+		}
+		return appendJSONTime(buf, time.Unix(0, f.Integer), e.opts.timeLayout)
+	case xfield.DurationType:
+		return appendJSONString(buf, time.Duration(f.Integer).String())
+	case xfield.ErrorType:
+		return appendJSONString(buf, f.FormatValue())
+	case xfield.ArrayType:
+		return e.appendArray(buf, f.Interface)
+	case xfield.GroupType:
+		if fields, ok := f.Interface.([]xfield.Field); ok {
+			buf = append(buf, '{')
+			buf = e.appendFields(buf, fields)
+			return append(buf, '}')
+		}
+		return appendJSONAny(buf, f.Interface)
+	case xfield.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			buf = append(buf, '"')
+			buf = base64.StdEncoding.AppendEncode(buf, b)
+			return append(buf, '"')
+		}
+		return appendJSONAny(buf, f.Interface)
+	default:
+		return appendJSONAny(buf, f.Interface)
+	}
+}
+
+// appendArray appends common slice types without reflection.
+//
+//nolint:gocyclo // switch on slice types requires many cases
+func (e *jsonEncoder) appendArray(buf []byte, v any) []byte {
+	switch arr := v.(type) {
+	case []string:
+		return appendJSONArray(buf, arr, appendJSONString)
+	case []int:
+		return appendJSONArray(buf, arr, func(b []byte, n int) []byte { return strconv.AppendInt(b, int64(n), 10) })
+	case []int32:
+		return appendJSONArray(buf, arr, func(b []byte, n int32) []byte { return strconv.AppendInt(b, int64(n), 10) })
+	case []int64:
+		return appendJSONArray(buf, arr, func(b []byte, n int64) []byte { return strconv.AppendInt(b, n, 10) })
+	case []uint:
+		return appendJSONArray(buf, arr, func(b []byte, n uint) []byte { return strconv.AppendUint(b, uint64(n), 10) })
+	case []uint32:
+		return appendJSONArray(buf, arr, func(b []byte, n uint32) []byte { return strconv.AppendUint(b, uint64(n), 10) })
+	case []uint64:
+		return appendJSONArray(buf, arr, func(b []byte, n uint64) []byte { return strconv.AppendUint(b, n, 10) })
+	case []float32:
+		return appendJSONArray(buf, arr, func(b []byte, n float32) []byte { return appendJSONFloat(b, float64(n), 32) })
+	case []float64:
+		return appendJSONArray(buf, arr, func(b []byte, n float64) []byte { return appendJSONFloat(b, n, 64) })
+	case []bool:
+		return appendJSONArray(buf, arr, strconv.AppendBool)
+	case []time.Duration:
+		return appendJSONArray(buf, arr, func(b []byte, d time.Duration) []byte { return appendJSONString(b, d.String()) })
+	default:
+		return appendJSONAny(buf, v)
+	}
+}
+
+// skipField reports whether the field produces no output, like nil errors in the adapters.
+func skipField(f *xfield.Field) bool {
+	if f.Type != xfield.ErrorType {
+		return false
+	}
+	err, ok := f.Interface.(error)
+	return !ok || err == nil
+}
+
+// appendJSONKey appends the key, preceded by a comma unless it's the first one in the object.
+// Fields pre-encoded by With start from an empty buffer and get no leading comma.
+func appendJSONKey(buf []byte, key string) []byte {
+	if last := len(buf) - 1; last >= 0 && buf[last] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+func appendJSONArray[T any](buf []byte, arr []T, appendElem func([]byte, T) []byte) []byte {
+	buf = append(buf, '[')
+	for i, v := range arr {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendElem(buf, v)
+	}
+	return append(buf, ']')
+}
+
+func appendJSONTime(buf []byte, t time.Time, layout string) []byte {
+	buf = append(buf, '"')
+	buf = t.AppendFormat(buf, layout)
+	return append(buf, '"')
+}
+
+// appendJSONFloat appends a float the way zap does: NaN and infinities are quoted strings.
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Inf"`...)
+	default:
+		return strconv.AppendFloat(buf, f, 'f', -1, bitSize)
+	}
+}
+
+// appendJSONAny appends an arbitrary value, falling back to encoding/json.
+// Values that can't be marshaled are written as their fmt representation.
+func appendJSONAny(buf []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, val)
+	case error:
+		return appendJSONString(buf, val.Error())
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprintf("%+v", v))
+	}
+	return append(buf, data...)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string, escaping control characters
+// and replacing invalid UTF-8 with the replacement character.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `�`...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
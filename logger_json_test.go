@@ -0,0 +1,317 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+func initJSONLogger(t *testing.T, options ...LoggerOption) (Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	options = append([]LoggerOption{
+		WithMinLevel(DebugLevel),
+		WithClock(func() time.Time { return testTime }),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	}, options...)
+
+	return NewJSONLogger(buf, options...), buf
+}
+
+func decodeJSONLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLogger(t *testing.T) {
+	t.Run("encodes entry", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		logger.Info("hello", xfield.String("key", "value"))
+
+		assert.Equal(t,
+			`{"time":"2024-01-02T03:04:05.000006Z","level":"info","msg":"hello","key":"value"}`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		logger.Panic("panic")
+		logger.Fatal("fatal")
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 6)
+		for i, level := range []string{"debug", "info", "warn", "error", "panic", "fatal"} {
+			assert.Equal(t, level, entries[i]["level"])
+			assert.Equal(t, level, entries[i]["msg"])
+		}
+	})
+
+	t.Run("fatal and panic call hooks", func(t *testing.T) {
+		var exited bool
+		var panicked string
+		logger, _ := initJSONLogger(t,
+			WithFatalHook(func() { exited = true }),
+			WithPanicHook(func(msg string) { panicked = msg }),
+		)
+
+		logger.Fatal("fatal")
+		logger.Panic("panic")
+
+		assert.True(t, exited)
+		assert.Equal(t, "panic", panicked)
+	})
+
+	t.Run("dpanic calls the panic hook in development", func(t *testing.T) {
+		var panicked string
+		logger, buf := initJSONLogger(t, WithPanicHook(func(msg string) { panicked = msg }))
+
+		logger.(DPanicLogger).DPanic("production")
+		assert.Empty(t, panicked)
+
+		t.Cleanup(ReplaceDevelopment(true))
+		logger.(DPanicLogger).DPanic("development")
+		assert.Equal(t, "development", panicked)
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "dpanic", entries[0]["level"])
+		assert.Equal(t, "dpanic", entries[1]["level"])
+	})
+
+	t.Run("panics by default", func(t *testing.T) {
+		logger := NewJSONLogger(&bytes.Buffer{})
+		assert.PanicsWithValue(t, "boom", func() {
+			logger.Panic("boom")
+		})
+	})
+
+	t.Run("min level", func(t *testing.T) {
+		logger, buf := initJSONLogger(t, WithMinLevel(WarnLevel))
+
+		logger.Info("hidden")
+		logger.Warn("visible")
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "visible", entries[0]["msg"])
+	})
+
+	t.Run("level override and controller", func(t *testing.T) {
+		controller := NewLevelController(WarnLevel)
+		logger, buf := initJSONLogger(t, WithLevelController(controller))
+
+		logger.Named("db").Info("hidden")
+		controller.SetLevel("db", DebugLevel)
+		logger.Named("db").Debug("db debug")
+		logger.Info("hidden")
+		logger.(LevelOverrider).WithLevelOverride(DebugLevel).Debug("override")
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "db debug", entries[0]["msg"])
+		assert.Equal(t, "override", entries[1]["msg"])
+	})
+
+	t.Run("with and named", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		child := logger.With(xfield.String("service", "api")).
+			Named("http").
+			With(xfield.Int("attempt", 1)).
+			Named("client")
+		child.Info("request", xfield.Bool("ok", true))
+		logger.Info("parent")
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "http.client", entries[0]["logger"])
+		assert.Equal(t, "api", entries[0]["service"])
+		assert.Equal(t, float64(1), entries[0]["attempt"])
+		assert.Equal(t, true, entries[0]["ok"])
+		assert.NotContains(t, entries[1], "service")
+		assert.NotContains(t, entries[1], "logger")
+	})
+
+	t.Run("custom keys and time format", func(t *testing.T) {
+		logger, buf := initJSONLogger(t,
+			WithTimeKey("ts"),
+			WithLevelKey("severity"),
+			WithNameKey("component"),
+			WithMessageKey("message"),
+			WithTimeFormat(time.DateOnly),
+		)
+
+		logger.Named("db").Info("hello")
+
+		assert.Equal(t,
+			`{"ts":"2024-01-02","severity":"info","component":"db","message":"hello"}`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("omitted keys", func(t *testing.T) {
+		logger, buf := initJSONLogger(t,
+			WithTimeKey(""),
+			WithLevelKey(""),
+			WithMessageKey(""),
+		)
+
+		logger.With(xfield.Int("a", 1)).Info("hello", xfield.Int("b", 2))
+
+		assert.Equal(t, `{"a":1,"b":2}`+"\n", buf.String())
+	})
+
+	t.Run("all field types", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		logger.Info("types",
+			xfield.String("string", "value"),
+			xfield.Int64("int", -42),
+			xfield.Uint64("uint", math.MaxUint64),
+			xfield.Float64("float", 3.5),
+			xfield.Float64("nan", math.NaN()),
+			xfield.Bool("bool", true),
+			xfield.Time("time", testTime),
+			xfield.Duration("duration", 1500*time.Millisecond),
+			xfield.Error(errors.New("boom")),
+			xfield.Error(nil),
+			xfield.NamedError("cause", errors.New("root")),
+			xfield.Strings("strings", []string{"a", "b"}),
+			xfield.Ints("ints", []int{1, 2}),
+			xfield.Int32s("int32s", []int32{3}),
+			xfield.Int64s("int64s", []int64{4}),
+			xfield.UInts("uints", []uint{5}),
+			xfield.UInt32s("uint32s", []uint32{6}),
+			xfield.UInt64s("uint64s", []uint64{7}),
+			xfield.Float32s("float32s", []float32{1.5}),
+			xfield.Float64s("float64s", []float64{2.5}),
+			xfield.Bools("bools", []bool{true, false}),
+			xfield.Durations("durations", []time.Duration{time.Second}),
+			xfield.Binary("binary", []byte("hi")),
+			xfield.Object("object", map[string]int{"a": 1}),
+			xfield.Any("any", struct{ Name string }{Name: "x"}),
+			xfield.Any("nil", nil),
+			xfield.Any("chan", make(chan int)),
+		)
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		assert.Equal(t, "value", entry["string"])
+		assert.Equal(t, float64(-42), entry["int"])
+		assert.Contains(t, buf.String(), `"uint":18446744073709551615`)
+		assert.Equal(t, 3.5, entry["float"])
+		assert.Equal(t, "NaN", entry["nan"])
+		assert.Equal(t, true, entry["bool"])
+		assert.Equal(t, "2024-01-02T03:04:05.000006Z", entry["time"])
+		assert.Equal(t, "1.5s", entry["duration"])
+		assert.Equal(t, "boom", entry["error"])
+		assert.Equal(t, "root", entry["cause"])
+		assert.Equal(t, []any{"a", "b"}, entry["strings"])
+		assert.Equal(t, []any{float64(1), float64(2)}, entry["ints"])
+		assert.Equal(t, []any{float64(3)}, entry["int32s"])
+		assert.Equal(t, []any{float64(4)}, entry["int64s"])
+		assert.Equal(t, []any{float64(5)}, entry["uints"])
+		assert.Equal(t, []any{float64(6)}, entry["uint32s"])
+		assert.Equal(t, []any{float64(7)}, entry["uint64s"])
+		assert.Equal(t, []any{1.5}, entry["float32s"])
+		assert.Equal(t, []any{2.5}, entry["float64s"])
+		assert.Equal(t, []any{true, false}, entry["bools"])
+		assert.Equal(t, []any{"1s"}, entry["durations"])
+		assert.Equal(t, "aGk=", entry["binary"])
+		assert.Equal(t, map[string]any{"a": float64(1)}, entry["object"])
+		assert.Equal(t, map[string]any{"Name": "x"}, entry["any"])
+		assert.Nil(t, entry["nil"])
+		assert.Contains(t, entry["chan"], "0x")
+	})
+
+	t.Run("group field", func(t *testing.T) {
+		logger, buf := initJSONLogger(t, WithTimeKey(""))
+
+		logger.With(xfield.Group("empty")).Info("hello",
+			xfield.Group("http", xfield.String("method", "GET"), xfield.Group("response", xfield.Int("status", 200))),
+		)
+
+		assert.Equal(t,
+			`{"level":"info","msg":"hello","empty":{},"http":{"method":"GET","response":{"status":200}}}`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("escapes strings", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		logger.Info("line\n\"quoted\"\t\\", xfield.String("ctl\x01", "bad\xffutf8 ünï"))
+
+		entries := decodeJSONLines(t, buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "line\n\"quoted\"\t\\", entries[0]["msg"])
+		assert.Equal(t, "bad�utf8 ünï", entries[0]["ctl\x01"])
+	})
+
+	t.Run("concurrent writes produce whole lines", func(t *testing.T) {
+		logger, buf := initJSONLogger(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				logger.With(xfield.Int("worker", i)).Info("message", xfield.String("payload", strings.Repeat("x", 100)))
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Len(t, decodeJSONLines(t, buf), 20)
+	})
+
+	t.Run("sync flushes syncer", func(t *testing.T) {
+		out := &syncBuffer{}
+		logger := NewJSONLogger(out)
+
+		require.NoError(t, logger.Sync())
+		assert.Equal(t, 1, out.synced)
+		require.NoError(t, NewJSONLogger(&bytes.Buffer{}).Sync())
+	})
+}
+
+type syncBuffer struct {
+	bytes.Buffer
+	synced int
+}
+
+func (b *syncBuffer) Sync() error {
+	b.synced++
+	return nil
+}
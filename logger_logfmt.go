@@ -0,0 +1,191 @@
+package xlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// NewLogfmtLogger creates a Logger writing one logfmt line per entry to w:
+//
+//	time=2024-01-01T00:00:00Z level=info logger=http msg="request processed" status=200
+//
+// The time, level, logger name and message come first, followed by the fields in the order
+// they were added. Group and Object fields are flattened into dotted keys ("http.method"),
+// with the keys of objects sorted. Values containing spaces, quotes, '=' or control characters
+// are quoted and escaped; characters that aren't allowed in keys are replaced with '_'.
+// Lines can be read back with ParseLogfmt.
+//
+// Example:
+//
+//	logger := xlog.NewLogfmtLogger(os.Stdout)
+//	logger.Info("request processed", xfield.Group("http", xfield.String("method", "GET")))
+//	// time=2024-01-01T00:00:00Z level=info msg="request processed" http.method=GET
+func NewLogfmtLogger(w io.Writer, options ...LoggerOption) Logger {
+	opts := newLoggerOptions(options)
+	return newNativeLogger(w, &logfmtEncoder{opts: opts}, opts)
+}
+
+// logfmtEncoder encodes entries as logfmt lines.
+type logfmtEncoder struct {
+	opts *loggerOptions
+}
+
+func (e *logfmtEncoder) appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	if e.opts.timeKey != "" {
+		buf = appendLogfmtKey(buf, e.opts.timeKey)
+		buf = appendLogfmtValue(buf, rec.time.Format(e.opts.timeLayout))
+	}
+	if e.opts.levelKey != "" {
+		buf = appendLogfmtKey(buf, e.opts.levelKey)
+		buf = appendLogfmtValue(buf, rec.level.String())
+	}
+	if e.opts.nameKey != "" && rec.name != "" {
+		buf = appendLogfmtKey(buf, e.opts.nameKey)
+		buf = appendLogfmtValue(buf, rec.name)
+	}
+	if e.opts.messageKey != "" {
+		buf = appendLogfmtKey(buf, e.opts.messageKey)
+		buf = appendLogfmtValue(buf, rec.message)
+	}
+	if len(context) > 0 {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, context...)
+	}
+	buf = e.appendFields(buf, fields)
+	return append(buf, '\n')
+}
+
+func (e *logfmtEncoder) appendFields(buf []byte, fields []xfield.Field) []byte {
+	for i := range fields {
+		buf = appendFlatField(buf, "", &fields[i], e.opts.timeLayout, appendLogfmtPair)
+	}
+	return buf
+}
+
+func appendLogfmtPair(buf []byte, key, value string) []byte {
+	buf = appendLogfmtKey(buf, key)
+	return appendLogfmtValue(buf, value)
+}
+
+// appendLogfmtKey appends the key followed by '=', preceded by a space unless the buffer is empty.
+// Characters that would make the key ambiguous are replaced with '_'.
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	if key == "" {
+		return append(buf, '_', '=')
+	}
+
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			buf = append(buf, '_')
+			continue
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return append(buf, '=')
+}
+
+// appendLogfmtValue appends the value, quoted and escaped if needed.
+func appendLogfmtValue(buf []byte, value string) []byte {
+	if !logfmtNeedsQuote(value) {
+		return append(buf, value...)
+	}
+	return appendJSONString(buf, value)
+}
+
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogfmtPair is a key-value pair of a logfmt line.
+type LogfmtPair struct {
+	Key   string
+	Value string
+}
+
+// ParseLogfmt parses a logfmt line into its key-value pairs, in order.
+// Quoted values are unescaped; a key without '=' has an empty value.
+func ParseLogfmt(line string) ([]LogfmtPair, error) {
+	var pairs []LogfmtPair
+
+	i := 0
+	for {
+		for i < len(line) && isLogfmtSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return pairs, nil
+		}
+
+		start := i
+		for i < len(line) && !isLogfmtSpace(line[i]) && line[i] != '=' {
+			if line[i] == '"' {
+				return nil, fmt.Errorf("unexpected '\"' in key at offset %d", i)
+			}
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("empty key at offset %d", i)
+		}
+		pair := LogfmtPair{Key: line[start:i]}
+
+		if i < len(line) && line[i] == '=' {
+			i++
+			value, n, err := parseLogfmtValue(line[i:])
+			if err != nil {
+				return nil, fmt.Errorf("value of %q: %w", pair.Key, err)
+			}
+			pair.Value = value
+			i += n
+		}
+		pairs = append(pairs, pair)
+	}
+}
+
+// parseLogfmtValue parses a value at the start of s and returns it with the number of bytes consumed.
+func parseLogfmtValue(s string) (string, int, error) {
+	if s == "" || s[0] != '"' {
+		end := strings.IndexFunc(s, func(r rune) bool { return r < utf8.RuneSelf && isLogfmtSpace(byte(r)) })
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], end, nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, err
+			}
+			return value, i + 1, nil
+		}
+	}
+	return "", 0, errors.New("unterminated quoted value")
+}
+
+func isLogfmtSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,207 @@
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func initLogfmtLogger(t *testing.T, options ...LoggerOption) (Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	options = append([]LoggerOption{
+		WithMinLevel(DebugLevel),
+		WithClock(func() time.Time { return testTime }),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	}, options...)
+
+	return NewLogfmtLogger(buf, options...), buf
+}
+
+func parseLogfmtLines(t *testing.T, buf *bytes.Buffer) [][]LogfmtPair {
+	t.Helper()
+
+	var entries [][]LogfmtPair
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		pairs, err := ParseLogfmt(line)
+		require.NoError(t, err, line)
+		entries = append(entries, pairs)
+	}
+	return entries
+}
+
+func TestLogfmtLogger(t *testing.T) {
+	t.Run("encodes entry", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t)
+
+		logger.Named("http").Info("request processed", xfield.Int("user_id", 123), xfield.String("path", "/users"))
+
+		assert.Equal(t,
+			`time=2024-01-02T03:04:05.000006Z level=info logger=http msg="request processed" user_id=123 path=/users`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("with keeps context order", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithTimeKey(""), WithLevelKey(""))
+
+		child := logger.With(xfield.String("a", "1")).With(xfield.String("b", "2"))
+		child.Info("hello", xfield.String("c", "3"))
+		logger.Info("parent")
+		NewLogfmtLogger(buf, WithTimeKey(""), WithLevelKey(""), WithMessageKey("")).With(xfield.String("only", "context")).Info("")
+
+		assert.Equal(t, "msg=hello a=1 b=2 c=3\nmsg=parent\nonly=context\n", buf.String())
+	})
+
+	t.Run("flattens groups and objects", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithTimeKey(""), WithLevelKey(""), WithMessageKey(""))
+
+		type user struct {
+			Name    string            `json:"name"`
+			Age     int               `json:"age"`
+			Tags    []string          `json:"tags"`
+			Labels  map[string]string `json:"labels"`
+			Manager *user             `json:"manager"`
+		}
+
+		logger.Info("",
+			xfield.Group("http",
+				xfield.String("method", "GET"),
+				xfield.Group("response", xfield.Int("status", 200)),
+			),
+			xfield.Object("user", user{Name: "john doe", Age: 30, Tags: []string{"a"}, Labels: map[string]string{"z": "1", "b": "2"}}),
+			xfield.Object("unmarshalable", make(chan int)),
+			xfield.Group("empty"),
+		)
+
+		line := buf.String()
+		assert.True(t, strings.HasPrefix(line,
+			`http.method=GET http.response.status=200 user.age=30 user.labels.b=2 user.labels.z=1 user.manager=null user.name="john doe" user.tags="[\"a\"]" unmarshalable=0x`,
+		), line)
+	})
+
+	t.Run("quotes and escapes", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithTimeKey(""), WithLevelKey(""), WithMessageKey(""))
+
+		logger.Info("",
+			xfield.String("empty", ""),
+			xfield.String("space", "a b"),
+			xfield.String("eq", "a=b"),
+			xfield.String("quote", `say "hi"`),
+			xfield.String("backslash", `C:\dir`),
+			xfield.String("newline", "a\nb"),
+			xfield.String("ctl", "a\x01"),
+			xfield.String("utf8", "ünï"),
+			xfield.String("invalid", "bad\xff"),
+			xfield.String("bad key=\"x\"", "v"),
+			xfield.String("", "v"),
+		)
+
+		assert.Equal(t,
+			`empty="" space="a b" eq="a=b" quote="say \"hi\"" backslash="C:\\dir" newline="a\nb" ctl="a\u0001" `+
+				`utf8=ünï invalid="bad`+"\uFFFD"+`" bad_key__x_=v _=v`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("all field types", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithTimeKey(""), WithLevelKey(""), WithMessageKey(""))
+
+		logger.Info("",
+			xfield.Int64("int", -1),
+			xfield.Uint64("uint", math.MaxUint64),
+			xfield.Float64("float", 1.5),
+			xfield.Float64("nan", math.NaN()),
+			xfield.Bool("bool", true),
+			xfield.Time("time", testTime),
+			xfield.Duration("duration", 1500*time.Millisecond),
+			xfield.Error(errors.New("boom")),
+			xfield.Error(nil),
+			xfield.Ints("ints", []int{1, 2}),
+			xfield.Binary("binary", []byte("hi")),
+		)
+
+		assert.Equal(t,
+			`int=-1 uint=18446744073709551615 float=1.5 nan=NaN bool=true time=2024-01-02T03:04:05.000006Z `+
+				`duration=1.5s error=boom ints="[1 2]" binary="aGk="`+"\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithTimeKey(""))
+
+		values := []string{"", "plain", "a b", "a=b", `"quoted"`, `back\slash`, "multi\nline\r\n", "tab\there", "\x00\x1f", "ünï 日本"}
+		for _, value := range values {
+			logger.Warn(value, xfield.String("value", value))
+		}
+
+		entries := parseLogfmtLines(t, buf)
+		require.Len(t, entries, len(values))
+		for i, value := range values {
+			assert.Equal(t, []LogfmtPair{
+				{Key: "level", Value: "warn"},
+				{Key: "msg", Value: value},
+				{Key: "value", Value: value},
+			}, entries[i])
+		}
+	})
+
+	t.Run("level override and controller", func(t *testing.T) {
+		logger, buf := initLogfmtLogger(t, WithMinLevel(InfoLevel), WithTimeKey(""), WithLevelKey(""))
+
+		logger.Debug("hidden")
+		overrideLevel(logger, DebugLevel).Debug("visible")
+		NewLevelController(ErrorLevel).Apply(logger).Warn("hidden")
+
+		assert.Equal(t, "msg=visible\n", buf.String())
+	})
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []LogfmtPair
+		wantErr string
+	}{
+		{name: "empty", line: "  "},
+		{
+			name: "bare and quoted values",
+			line: `a=1 b="x y" c= d e="esc\"aped\n"`,
+			want: []LogfmtPair{{"a", "1"}, {"b", "x y"}, {"c", ""}, {"d", ""}, {"e", "esc\"aped\n"}},
+		},
+		{
+			name: "extra whitespace",
+			line: "\ta=1   b=2 \n",
+			want: []LogfmtPair{{"a", "1"}, {"b", "2"}},
+		},
+		{name: "empty key", line: "=1", wantErr: "empty key"},
+		{name: "quote in key", line: `a"b=1`, wantErr: "unexpected"},
+		{name: "unterminated quote", line: `a="x`, wantErr: "unterminated"},
+		{name: "invalid escape", line: `a="\q"`, wantErr: "invalid syntax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := ParseLogfmt(tt.line)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pairs)
+		})
+	}
+}
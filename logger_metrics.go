@@ -0,0 +1,107 @@
+package xlog
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// meterName is the instrumentation scope of the metrics hook.
+const meterName = "github.com/ruko1202/xlog"
+
+// MetricsOption is a function that configures NewMetricsHook.
+type MetricsOption func(*metricsOptions)
+
+type metricsOptions struct {
+	provider metric.MeterProvider
+}
+
+// WithMeterProvider sets the meter provider the instruments are created with.
+// The default is otel.GetMeterProvider().
+func WithMeterProvider(provider metric.MeterProvider) MetricsOption {
+	return func(o *metricsOptions) {
+		o.provider = provider
+	}
+}
+
+// NewMetricsHook returns a Hook counting entries with OTel metric instruments:
+//
+//   - xlog.log_entries counts entries by "level" and "logger" (the name built by Named, see Entry.LoggerName),
+//   - xlog.log_errors counts the non-nil error fields by "level", "logger" and "error.type",
+//     the Go type of the error wrapped by fmt.Errorf, NewErr or WrapErr (e.g. "*fs.PathError").
+//
+// The message and fields never become attributes, to keep the cardinality bounded.
+// The hook counts the entries it sees, only the ones at levels enabled by the inner logger,
+// so register it after the hooks that drop entries. The counters are incremented with the
+// context of the entry, so exemplars can link them to the current span.
+//
+// Example:
+//
+//	hook, err := xlog.NewMetricsHook(xlog.WithMeterProvider(meterProvider))
+//	if err != nil {
+//		return err
+//	}
+//	logger := xlog.WithHooks(xlog.NewJSONLogger(os.Stdout), hook)
+func NewMetricsHook(options ...MetricsOption) (Hook, error) {
+	opts := &metricsOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.provider == nil {
+		opts.provider = otel.GetMeterProvider()
+	}
+
+	meter := opts.provider.Meter(meterName)
+	entries, err := meter.Int64Counter("xlog.log_entries",
+		metric.WithDescription("Number of log entries by level and logger."),
+		metric.WithUnit("{entry}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create xlog.log_entries counter: %w", err)
+	}
+	errorsCounter, err := meter.Int64Counter("xlog.log_errors",
+		metric.WithDescription("Number of errors logged by level, logger and error type."),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create xlog.log_errors counter: %w", err)
+	}
+
+	return func(entry *Entry) bool {
+		level := attribute.String("level", entry.Level.String())
+		logger := attribute.String("logger", entry.LoggerName)
+		entries.Add(entry.Ctx, 1, metric.WithAttributes(level, logger))
+
+		forEachError(entry.Fields, func(err error) {
+			errorsCounter.Add(entry.Ctx, 1, metric.WithAttributes(level, logger,
+				attribute.String("error.type", errorType(err)),
+			))
+		})
+		return true
+	}, nil
+}
+
+// forEachError calls fn for every non-nil error field, including the fields of groups.
+func forEachError(fields []xfield.Field, fn func(err error)) {
+	for i := range fields {
+		switch fields[i].Type {
+		case xfield.ErrorType:
+			if err, ok := fields[i].Interface.(error); ok && err != nil {
+				fn(err)
+			}
+		case xfield.GroupType:
+			if nested, ok := fields[i].Interface.([]xfield.Field); ok {
+				forEachError(nested, fn)
+			}
+		}
+	}
+}
+
+// errorType returns the Go type of the error, looking through wrappers (see isWrapper).
+func errorType(err error) string {
+	return fmt.Sprintf("%T", unwrapCause(err))
+}
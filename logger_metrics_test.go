@@ -0,0 +1,169 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// collectCounters reads the counters and returns their values by metric name and attribute set.
+func collectCounters(t *testing.T, reader sdkmetric.Reader) map[string]map[attribute.Distinct]int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	counters := map[string]map[attribute.Distinct]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		assert.Equal(t, meterName, sm.Scope.Name)
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok, m.Name)
+			counters[m.Name] = map[attribute.Distinct]int64{}
+			for _, dp := range sum.DataPoints {
+				counters[m.Name][dp.Attributes.Equivalent()] = dp.Value
+			}
+		}
+	}
+	return counters
+}
+
+func attrs(kv ...attribute.KeyValue) attribute.Distinct {
+	set := attribute.NewSet(kv...)
+	return set.Equivalent()
+}
+
+func TestMetricsHook(t *testing.T) {
+	t.Run("counts entries and errors", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		hook, err := NewMetricsHook(WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		require.NoError(t, err)
+
+		logger, _ := initHookedLogger(t, hook)
+		http := logger.Named("http")
+
+		logger.Info("started")
+		http.Info("request 1")
+		http.Info("request 2")
+		http.Debug("details")
+		http.Error("request failed",
+			xfield.Error(fmt.Errorf("read config: %w", &fs.PathError{Op: "open", Path: "/etc/app", Err: fs.ErrNotExist})),
+			xfield.Group("retry", xfield.NamedError("last", errors.New("timeout"))),
+			xfield.NamedError("ignored", nil),
+		)
+
+		level := attribute.Key("level")
+		name := attribute.Key("logger")
+		counters := collectCounters(t, reader)
+		assert.Equal(t, map[attribute.Distinct]int64{
+			attrs(level.String("info"), name.String("")):      1,
+			attrs(level.String("info"), name.String("http")):  2,
+			attrs(level.String("debug"), name.String("http")): 1,
+			attrs(level.String("error"), name.String("http")): 1,
+		}, counters["xlog.log_entries"])
+
+		errorType := attribute.Key("error.type")
+		assert.Equal(t, map[attribute.Distinct]int64{
+			attrs(level.String("error"), name.String("http"), errorType.String("*fs.PathError")):       1,
+			attrs(level.String("error"), name.String("http"), errorType.String("*errors.errorString")): 1,
+		}, counters["xlog.log_errors"])
+	})
+
+	t.Run("counts entries that reach it", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		hook, err := NewMetricsHook(WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		require.NoError(t, err)
+
+		logger, _ := initHookedLogger(t, func(e *Entry) bool { return e.Message != "dropped" }, hook)
+		logger.Warn("dropped")
+		logger.Warn("kept")
+
+		counters := collectCounters(t, reader)
+		assert.Equal(t, map[attribute.Distinct]int64{
+			attrs(attribute.String("level", "warn"), attribute.String("logger", "")): 1,
+		}, counters["xlog.log_entries"])
+	})
+
+	t.Run("counts only enabled levels with the full logger name", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		hook, err := NewMetricsHook(WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		require.NoError(t, err)
+
+		inner := NewLogfmtLogger(&bytes.Buffer{}, WithMinLevel(InfoLevel)).Named("app")
+		logger := WithHooks(inner, hook).Named("http")
+		logger.Debug("hidden")
+		logger.Info("visible")
+
+		counters := collectCounters(t, reader)
+		assert.Equal(t, map[attribute.Distinct]int64{
+			attrs(attribute.String("level", "info"), attribute.String("logger", "app.http")): 1,
+		}, counters["xlog.log_entries"])
+	})
+
+	t.Run("uses the global meter provider by default", func(t *testing.T) {
+		hook, err := NewMetricsHook()
+		require.NoError(t, err)
+		assert.True(t, hook(&Entry{Ctx: context.Background()}))
+	})
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "*errors.errorString", errorType(errors.New("plain")))
+	assert.Equal(t, "*fs.PathError", errorType(fmt.Errorf("a: %w", fmt.Errorf("b: %w", &fs.PathError{Err: fs.ErrNotExist}))))
+	assert.Equal(t, "*fmt.wrapErrors", errorType(fmt.Errorf("%w: %w", errors.New("a"), errors.New("b"))))
+	assert.Equal(t, "*errors.joinError", errorType(errors.Join(errors.New("a"), errors.New("b"))))
+	assert.Equal(t, "*fs.PathError", errorType(&prefixError{prefix: "load", err: &fs.PathError{Err: fs.ErrNotExist}}))
+	assert.Equal(t, "*xlog.hidingError", errorType(&hidingError{err: errors.New("secret")}))
+	assert.Equal(t, "*xlog.PublicError", errorType(&PublicError{err: errors.New("cause")}))
+}
+
+// prefixError adds context to the message of the error it wraps.
+type prefixError struct {
+	prefix string
+	err    error
+}
+
+func (e *prefixError) Error() string {
+	return e.prefix + ": " + e.err.Error()
+}
+
+func (e *prefixError) Unwrap() error {
+	return e.err
+}
+
+// hidingError wraps an error without repeating its message.
+type hidingError struct {
+	err error
+}
+
+func (e *hidingError) Error() string {
+	return "failed"
+}
+
+func (e *hidingError) Unwrap() error {
+	return e.err
+}
+
+// PublicError is an exported wrapper, matched by callers with errors.As.
+type PublicError struct {
+	err error
+}
+
+func (e *PublicError) Error() string {
+	return "public: " + e.err.Error()
+}
+
+func (e *PublicError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,447 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// LoggerOption is a function that configures the native loggers (NewJSONLogger, etc).
+type LoggerOption func(*loggerOptions)
+
+// loggerOptions holds the configuration shared by all native loggers.
+type loggerOptions struct {
+	minLevel   Level
+	controller *LevelController
+	timeKey    string
+	levelKey   string
+	nameKey    string
+	messageKey string
+	timeLayout string
+	clock      func() time.Time
+	color      *bool
+	fatalHook  func()
+	panicHook  func(string)
+}
+
+func newLoggerOptions(options []LoggerOption) *loggerOptions {
+	opts := &loggerOptions{
+		minLevel:   InfoLevel,
+		timeKey:    "time",
+		levelKey:   "level",
+		nameKey:    "logger",
+		messageKey: "msg",
+		timeLayout: time.RFC3339Nano,
+		clock:      time.Now,
+		fatalHook: func() {
+			os.Exit(1)
+		},
+		panicHook: func(msg string) {
+			panic(msg)
+		},
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return opts
+}
+
+// WithMinLevel sets the minimum level of the logger. The default is InfoLevel.
+func WithMinLevel(level Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.minLevel = level
+	}
+}
+
+// WithLevelController makes the levels of the logger decided by the controller,
+// matching its overrides against the name built by Named.
+// The controller takes precedence over WithMinLevel.
+func WithLevelController(controller *LevelController) LoggerOption {
+	return func(o *loggerOptions) {
+		o.controller = controller
+	}
+}
+
+// WithTimeKey sets the key of the entry time. An empty key omits the time.
+func WithTimeKey(key string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.timeKey = key
+	}
+}
+
+// WithLevelKey sets the key of the entry level. An empty key omits the level.
+func WithLevelKey(key string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.levelKey = key
+	}
+}
+
+// WithNameKey sets the key of the logger name. An empty key omits the name.
+func WithNameKey(key string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.nameKey = key
+	}
+}
+
+// WithMessageKey sets the key of the message. An empty key omits the message.
+func WithMessageKey(key string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.messageKey = key
+	}
+}
+
+// WithTimeFormat sets the layout used to format the entry time and time fields.
+// The default is time.RFC3339Nano.
+func WithTimeFormat(layout string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.timeLayout = layout
+	}
+}
+
+// WithClock sets the function returning the entry time (for testing).
+func WithClock(clock func() time.Time) LoggerOption {
+	return func(o *loggerOptions) {
+		o.clock = clock
+	}
+}
+
+// WithColor forces ANSI colors of the console logger on or off.
+// By default colors are enabled only when the output is a terminal and NO_COLOR is not set.
+func WithColor(enabled bool) LoggerOption {
+	return func(o *loggerOptions) {
+		o.color = &enabled
+	}
+}
+
+// WithFatalHook sets a function called instead of os.Exit(1) after a Fatal entry (for testing).
+func WithFatalHook(fn func()) LoggerOption {
+	return func(o *loggerOptions) {
+		o.fatalHook = fn
+	}
+}
+
+// WithPanicHook sets a function called instead of panic after a Panic entry (for testing).
+func WithPanicHook(fn func(string)) LoggerOption {
+	return func(o *loggerOptions) {
+		o.panicHook = fn
+	}
+}
+
+// record is a single log entry passed to an encoder.
+type record struct {
+	time    time.Time
+	level   Level
+	name    string
+	message string
+}
+
+// encoder encodes entries of a native logger into a specific format.
+type encoder interface {
+	// appendFields appends the encoded fields, so they can be pre-encoded by With
+	// and placed between the entry header and the call-site fields.
+	appendFields(buf []byte, fields []xfield.Field) []byte
+	// appendRecord appends a complete encoded entry including the trailing newline.
+	appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte
+}
+
+// nativeCore is the state shared by a native logger and all its children.
+type nativeCore struct {
+	mu   sync.Mutex
+	out  io.Writer
+	enc  encoder
+	opts *loggerOptions
+}
+
+// nativeLogger is a Logger that encodes xfield.Field directly into a pooled buffer,
+// without converting them to the types of another logging library.
+type nativeLogger struct {
+	core       *nativeCore
+	name       string
+	context    []byte // fields pre-encoded by With
+	override   *Level
+	controller *LevelController
+}
+
+func newNativeLogger(out io.Writer, enc encoder, opts *loggerOptions) *nativeLogger {
+	return &nativeLogger{
+		core: &nativeCore{
+			out:  out,
+			enc:  enc,
+			opts: opts,
+		},
+		controller: opts.controller,
+	}
+}
+
+// Debug logs a debug-level message.
+func (l *nativeLogger) Debug(msg string, fields ...xfield.Field) {
+	l.log(DebugLevel, msg, fields)
+}
+
+// Info logs an info-level message.
+func (l *nativeLogger) Info(msg string, fields ...xfield.Field) {
+	l.log(InfoLevel, msg, fields)
+}
+
+// Warn logs a warning-level message.
+func (l *nativeLogger) Warn(msg string, fields ...xfield.Field) {
+	l.log(WarnLevel, msg, fields)
+}
+
+// Error logs an error-level message.
+func (l *nativeLogger) Error(msg string, fields ...xfield.Field) {
+	l.log(ErrorLevel, msg, fields)
+}
+
+// Fatal logs a fatal-level message, syncs the output and terminates the program.
+func (l *nativeLogger) Fatal(msg string, fields ...xfield.Field) {
+	l.log(FatalLevel, msg, fields)
+	_ = l.Sync()
+	l.core.opts.fatalHook()
+}
+
+// DPanic logs a dpanic-level message and, in development, panics, see ReplaceDevelopment.
+func (l *nativeLogger) DPanic(msg string, fields ...xfield.Field) {
+	l.log(DPanicLevel, msg, fields)
+	if IsDevelopment() {
+		l.core.opts.panicHook(msg)
+	}
+}
+
+// Panic logs a panic-level message and panics.
+func (l *nativeLogger) Panic(msg string, fields ...xfield.Field) {
+	l.log(PanicLevel, msg, fields)
+	l.core.opts.panicHook(msg)
+}
+
+// With creates a child logger with the fields pre-encoded.
+func (l *nativeLogger) With(fields ...xfield.Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	child := l.clone()
+	child.context = l.core.enc.appendFields(append([]byte(nil), l.context...), fields)
+	return child
+}
+
+// Named creates a child logger with the name appended to the name chain.
+func (l *nativeLogger) Named(name string) Logger {
+	child := l.clone()
+	child.name = joinLoggerName(l.name, name)
+	return child
+}
+
+// Sync flushes the output if it implements Sync() error (e.g. *os.File).
+func (l *nativeLogger) Sync() error {
+	if syncer, ok := l.core.out.(interface{ Sync() error }); ok {
+		l.core.mu.Lock()
+		defer l.core.mu.Unlock()
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// WithLevelOverride returns a child logger with the given minimum level.
+func (l *nativeLogger) WithLevelOverride(level Level) Logger {
+	child := l.clone()
+	child.override = &level
+	return child
+}
+
+// WithLevelController returns a child logger whose levels are decided by the controller.
+func (l *nativeLogger) WithLevelController(controller *LevelController) Logger {
+	child := l.clone()
+	child.override = nil
+	child.controller = controller
+	return child
+}
+
+// Enabled reports whether entries at the given level are written.
+func (l *nativeLogger) Enabled(level Level) bool {
+	return l.enabled(level)
+}
+
+func (l *nativeLogger) loggerName() string {
+	return l.name
+}
+
+func (l *nativeLogger) now() time.Time {
+	return l.core.opts.clock()
+}
+
+func (l *nativeLogger) clone() *nativeLogger {
+	child := *l
+	return &child
+}
+
+func (l *nativeLogger) enabled(level Level) bool {
+	switch {
+	case l.override != nil:
+		return level >= *l.override
+	case l.controller != nil:
+		return l.controller.Enabled(l.name, level)
+	default:
+		return level >= l.core.opts.minLevel
+	}
+}
+
+func (l *nativeLogger) log(level Level, msg string, fields []xfield.Field) {
+	if !l.enabled(level) {
+		return
+	}
+	l.write(l.core.opts.clock(), level, msg, fields)
+}
+
+func (l *nativeLogger) logAt(t time.Time, level Level, msg string, fields []xfield.Field) {
+	if !l.enabled(level) {
+		return
+	}
+	l.write(t, level, msg, fields)
+}
+
+func (l *nativeLogger) write(t time.Time, level Level, msg string, fields []xfield.Field) {
+	rec := record{
+		time:    t,
+		level:   level,
+		name:    l.name,
+		message: msg,
+	}
+
+	buf := getBuffer()
+	buf.b = l.core.enc.appendRecord(buf.b, rec, l.context, fields)
+
+	l.core.mu.Lock()
+	_, _ = l.core.out.Write(buf.b)
+	l.core.mu.Unlock()
+
+	putBuffer(buf)
+}
+
+// maxPooledBufferSize limits the capacity of buffers returned to the pool,
+// so a single huge entry doesn't pin memory forever.
+const maxPooledBufferSize = 64 << 10
+
+type buffer struct {
+	b []byte
+}
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return &buffer{b: make([]byte, 0, 1024)}
+	},
+}
+
+func getBuffer() *buffer {
+	buf := bufferPool.Get().(*buffer) //nolint:errcheck // the pool only holds *buffer
+	buf.b = buf.b[:0]
+	return buf
+}
+
+func putBuffer(buf *buffer) {
+	if cap(buf.b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// appendPair appends a key-value pair in the format of an encoder.
+type appendPair func(buf []byte, key, value string) []byte
+
+// appendFlatField appends the field as one or more key-value pairs for formats without nesting.
+// Groups and objects are flattened into dotted keys ("http.method"), with the keys of objects sorted.
+// Nil errors are skipped.
+func appendFlatField(buf []byte, prefix string, f *xfield.Field, timeLayout string, appendKV appendPair) []byte {
+	if skipField(f) {
+		return buf
+	}
+
+	key := prefix + f.Key
+	switch f.Type {
+	case xfield.GroupType:
+		nested, ok := f.Interface.([]xfield.Field)
+		if !ok {
+			break
+		}
+		for i := range nested {
+			buf = appendFlatField(buf, key+".", &nested[i], timeLayout, appendKV)
+		}
+		return buf
+	case xfield.ObjectType:
+		return appendFlatObject(buf, key, f.Interface, appendKV)
+	}
+
+	return appendKV(buf, key, formatFlatValue(f, timeLayout))
+}
+
+// formatFlatValue formats the field value as a string, like FormatValue but
+// with exact numbers, the given time layout and base64 for binary data.
+func formatFlatValue(f *xfield.Field, timeLayout string) string {
+	switch f.Type {
+	case xfield.Uint64Type:
+		// #nosec G115 - safe conversion as Uint64 values are stored as int64
+		return strconv.FormatUint(uint64(f.Integer), 10)
+	case xfield.Float64Type:
+		return strconv.FormatFloat(f.Float, 'f', -1, 64)
+	case xfield.TimeType:
+		if t, ok := f.Interface.(time.Time); ok {
+			return t.Format(timeLayout)
+		}
+		return time.Unix(0, f.Integer).Format(timeLayout)
+	case xfield.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			return base64.StdEncoding.EncodeToString(b)
+		}
+	}
+	return f.FormatValue()
+}
+
+// appendFlatObject flattens the JSON representation of the value into dotted keys.
+// Values that can't be marshaled are written as their fmt representation.
+func appendFlatObject(buf []byte, key string, v any, appendKV appendPair) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return appendKV(buf, key, fmt.Sprintf("%+v", v))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return appendKV(buf, key, string(data))
+	}
+	return appendFlatJSON(buf, key, decoded, appendKV)
+}
+
+func appendFlatJSON(buf []byte, key string, v any, appendKV appendPair) []byte {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf = appendFlatJSON(buf, key+"."+k, val[k], appendKV)
+		}
+		return buf
+	case string:
+		return appendKV(buf, key, val)
+	case []any:
+		data, _ := json.Marshal(val) //nolint:errchkjson // decoded JSON always marshals
+		return appendKV(buf, key, string(data))
+	case nil:
+		return appendKV(buf, key, "null")
+	default:
+		return appendKV(buf, key, fmt.Sprint(val))
+	}
+}
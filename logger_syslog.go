@@ -0,0 +1,325 @@
+package xlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// SyslogFormat is the syslog message format.
+type SyslogFormat int
+
+const (
+	// RFC5424 is the current syslog protocol, with structured data.
+	RFC5424 SyslogFormat = iota
+	// RFC3164 is the legacy BSD syslog format.
+	RFC3164
+)
+
+// SyslogBody is how the message and fields of an entry are encoded.
+type SyslogBody int
+
+const (
+	// SyslogStructuredData writes the fields as an RFC 5424 structured data element
+	// and the message as the MSG part.
+	SyslogStructuredData SyslogBody = iota
+	// SyslogJSON writes the message and fields as a JSON object in the MSG part.
+	SyslogJSON
+)
+
+// SyslogFacility is the syslog facility code.
+type SyslogFacility int
+
+// Syslog facilities used by applications.
+const (
+	SyslogUser   SyslogFacility = 1
+	SyslogDaemon SyslogFacility = 3
+	SyslogLocal0 SyslogFacility = 16
+	SyslogLocal1 SyslogFacility = 17
+	SyslogLocal2 SyslogFacility = 18
+	SyslogLocal3 SyslogFacility = 19
+	SyslogLocal4 SyslogFacility = 20
+	SyslogLocal5 SyslogFacility = 21
+	SyslogLocal6 SyslogFacility = 22
+	SyslogLocal7 SyslogFacility = 23
+)
+
+// DefaultSyslogSDID is the default structured data ID, using the enterprise number reserved for documentation.
+const DefaultSyslogSDID = "xlog@32473"
+
+// SyslogConfig configures a syslog logger. Empty values are replaced with defaults.
+type SyslogConfig struct {
+	// Format is the message format. The default is RFC5424.
+	Format SyslogFormat
+	// Body is how the message and fields are encoded. The default is SyslogStructuredData.
+	Body SyslogBody
+	// Facility is the facility of the messages. The default is SyslogUser.
+	Facility SyslogFacility
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// AppName defaults to the name of the executable.
+	AppName string
+	// ProcID defaults to the process ID.
+	ProcID string
+	// MsgID identifies the type of the messages (RFC 5424 only). The default is nil ("-").
+	MsgID string
+	// SDID is the ID of the structured data element holding the fields. The default is DefaultSyslogSDID.
+	SDID string
+}
+
+// NewSyslogLogger creates a Logger writing syslog messages to w, usually a sink.Network.
+// Each entry is written with a single Write and without a trailing newline,
+// framing is left to the transport. Line breaks in the message and the parameters
+// are escaped as \n and \r, so messages stay on one line as sink.NonTransparent requires.
+//
+// Levels are mapped to severities: debug to debug (7), info to informational (6),
+// warn to warning (4), error to error (3), dpanic to critical (2), panic to alert (1)
+// and fatal to emergency (0). The logger name is written as the "logger" parameter
+// and groups and objects are flattened into dotted parameter names. RFC 3164 has no
+// structured data, so the element is appended to the message.
+//
+// Example:
+//
+//	conn, err := sink.NewNetwork("udp", "localhost:514")
+//	if err != nil {
+//		return err
+//	}
+//	logger := xlog.NewSyslogLogger(conn, xlog.SyslogConfig{AppName: "api", Facility: xlog.SyslogLocal0})
+//	logger.Info("request processed", xfield.Int("status", 200))
+//	// <134>1 2024-01-01T00:00:00.000000Z host api 42 - [xlog@32473 status="200"] request processed
+func NewSyslogLogger(w io.Writer, cfg SyslogConfig, options ...LoggerOption) Logger {
+	opts := newLoggerOptions(options)
+
+	if cfg.Facility <= 0 {
+		cfg.Facility = SyslogUser
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if cfg.ProcID == "" {
+		cfg.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if cfg.SDID == "" {
+		cfg.SDID = DefaultSyslogSDID
+	}
+
+	enc := &syslogEncoder{
+		opts:     opts,
+		format:   cfg.Format,
+		facility: cfg.Facility,
+		hostname: syslogHeaderField(cfg.Hostname, 255),
+		appName:  syslogHeaderField(cfg.AppName, 48),
+		procID:   syslogHeaderField(cfg.ProcID, 128),
+		msgID:    syslogHeaderField(cfg.MsgID, 32),
+		sdID:     syslogSDName(cfg.SDID),
+	}
+	if cfg.Body == SyslogJSON {
+		jsonOpts := *opts
+		jsonOpts.timeKey = ""
+		enc.json = &jsonEncoder{opts: &jsonOpts}
+	}
+
+	return newNativeLogger(w, enc, opts)
+}
+
+// syslogEncoder encodes entries as syslog messages.
+type syslogEncoder struct {
+	opts     *loggerOptions
+	format   SyslogFormat
+	facility SyslogFacility
+	hostname string
+	appName  string
+	procID   string
+	msgID    string
+	sdID     string
+	json     *jsonEncoder
+}
+
+func (e *syslogEncoder) appendFields(buf []byte, fields []xfield.Field) []byte {
+	if e.json != nil {
+		return e.json.appendFields(buf, fields)
+	}
+	for i := range fields {
+		buf = appendFlatField(buf, "", &fields[i], e.opts.timeLayout, appendSyslogParam)
+	}
+	return buf
+}
+
+func (e *syslogEncoder) appendRecord(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(e.facility)*8+int64(syslogSeverity(rec.level)), 10)
+	buf = append(buf, '>')
+
+	if e.format == RFC3164 {
+		buf = rec.time.AppendFormat(buf, "Jan _2 15:04:05")
+		buf = append(buf, ' ')
+		buf = append(buf, e.hostname...)
+		buf = append(buf, ' ')
+		buf = append(buf, e.appName...)
+		buf = append(buf, '[')
+		buf = append(buf, e.procID...)
+		buf = append(buf, "]: "...)
+		if e.json != nil {
+			return e.appendJSONBody(buf, rec, context, fields)
+		}
+		buf = appendSyslogMessage(buf, rec.message)
+		start := len(buf)
+		buf = append(buf, ' ')
+		return e.appendStructuredData(buf, start, rec, context, fields)
+	}
+
+	buf = append(buf, '1', ' ')
+	buf = rec.time.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	for _, field := range [...]string{e.hostname, e.appName, e.procID, e.msgID} {
+		buf = append(buf, ' ')
+		buf = append(buf, field...)
+	}
+	buf = append(buf, ' ')
+
+	if e.json != nil {
+		buf = append(buf, '-', ' ')
+		return e.appendJSONBody(buf, rec, context, fields)
+	}
+
+	start := len(buf)
+	buf = e.appendStructuredData(buf, start, rec, context, fields)
+	if len(buf) == start {
+		buf = append(buf, '-')
+	}
+	if rec.message != "" {
+		buf = append(buf, ' ')
+		buf = appendSyslogMessage(buf, rec.message)
+	}
+	return buf
+}
+
+// appendStructuredData appends the structured data element holding the logger name and fields.
+// If there are none, the buffer is truncated to start.
+func (e *syslogEncoder) appendStructuredData(buf []byte, start int, rec record, context []byte, fields []xfield.Field) []byte {
+	buf = append(buf, '[')
+	buf = append(buf, e.sdID...)
+	params := len(buf)
+
+	if e.opts.nameKey != "" && rec.name != "" {
+		buf = appendSyslogParam(buf, e.opts.nameKey, rec.name)
+	}
+	buf = append(buf, context...)
+	buf = e.appendFields(buf, fields)
+
+	if len(buf) == params {
+		return buf[:start]
+	}
+	return append(buf, ']')
+}
+
+func (e *syslogEncoder) appendJSONBody(buf []byte, rec record, context []byte, fields []xfield.Field) []byte {
+	buf = e.json.appendRecord(buf, rec, context, fields)
+	return buf[:len(buf)-1] // trailing newline
+}
+
+// syslogSeverity maps the level to a syslog severity.
+func syslogSeverity(level Level) int {
+	switch {
+	case level <= DebugLevel:
+		return 7
+	case level == InfoLevel:
+		return 6
+	case level == WarnLevel:
+		return 4
+	case level == ErrorLevel:
+		return 3
+	case level == DPanicLevel:
+		return 2
+	case level == PanicLevel:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// appendSyslogParam appends ` name="value"`, escaping '"', '\' and ']' in the value.
+func appendSyslogParam(buf []byte, name, value string) []byte {
+	buf = append(buf, ' ')
+	buf = append(buf, syslogSDName(name)...)
+	buf = append(buf, '=', '"')
+
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"', '\\', ']':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = utf8.AppendRune(buf, utf8.RuneError)
+		} else {
+			buf = append(buf, value[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// appendSyslogMessage appends the message with its line breaks escaped as \n and \r.
+func appendSyslogMessage(buf []byte, msg string) []byte {
+	for i := 0; i < len(msg); i++ {
+		switch c := msg[i]; c {
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// syslogSDName returns a valid SD-NAME: printable ASCII except '=', ']', '"' and space,
+// at most 32 characters. Other characters are replaced with '_'.
+func syslogSDName(name string) string {
+	return sanitizeSyslog(name, 32, func(c byte) bool {
+		return c == '=' || c == ']' || c == '"'
+	})
+}
+
+// syslogHeaderField returns a valid header field: printable ASCII, at most maxLen characters,
+// or "-" if empty.
+func syslogHeaderField(value string, maxLen int) string {
+	return sanitizeSyslog(value, maxLen, func(byte) bool { return false })
+}
+
+func sanitizeSyslog(value string, maxLen int, reserved func(c byte) bool) string {
+	if value == "" {
+		return "-"
+	}
+
+	b := []byte(value)
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	for i, c := range b {
+		if c <= ' ' || c >= 0x7f || reserved(c) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
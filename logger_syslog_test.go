@@ -0,0 +1,245 @@
+package xlog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ruko1202/xlog/sink"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// recordingWriter keeps every Write as a separate message.
+type recordingWriter struct {
+	messages []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.messages = append(w.messages, string(p))
+	return len(p), nil
+}
+
+func initSyslogLogger(t *testing.T, cfg SyslogConfig, options ...LoggerOption) (Logger, *recordingWriter) {
+	t.Helper()
+
+	if cfg.Hostname == "" {
+		cfg.Hostname = "host"
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "app"
+	}
+	if cfg.ProcID == "" {
+		cfg.ProcID = "42"
+	}
+
+	out := &recordingWriter{}
+	options = append([]LoggerOption{
+		WithMinLevel(DebugLevel),
+		WithClock(func() time.Time { return testTime }),
+		WithFatalHook(func() {}),
+		WithPanicHook(func(string) {}),
+	}, options...)
+
+	return NewSyslogLogger(out, cfg, options...), out
+}
+
+func TestSyslogLogger(t *testing.T) {
+	t.Run("rfc5424 with structured data", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{MsgID: "req"})
+
+		logger.Named("http").With(xfield.String("service", "api")).Info("request processed",
+			xfield.Int("status", 200),
+			xfield.Group("user", xfield.String("name", `a "quoted" \ [name]`)),
+		)
+
+		require.Len(t, out.messages, 1)
+		assert.Equal(t,
+			`<14>1 2024-01-02T03:04:05.000006Z host app 42 req [xlog@32473 logger="http" service="api" status="200" user.name="a \"quoted\" \\ [name\]"] request processed`,
+			out.messages[0],
+		)
+	})
+
+	t.Run("rfc5424 without fields", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{})
+
+		logger.Info("hello", xfield.Error(nil))
+		logger.Info("")
+
+		assert.Equal(t, []string{
+			`<14>1 2024-01-02T03:04:05.000006Z host app 42 - - hello`,
+			`<14>1 2024-01-02T03:04:05.000006Z host app 42 - -`,
+		}, out.messages)
+	})
+
+	t.Run("maps levels to severities", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{Facility: SyslogLocal0})
+
+		logger.Debug("m")
+		logger.Info("m")
+		logger.Warn("m")
+		logger.Error("m")
+		logger.Panic("m")
+		logger.Fatal("m")
+
+		var priorities []string
+		for _, msg := range out.messages {
+			priorities = append(priorities, msg[:strings.IndexByte(msg, '>')+1])
+		}
+		assert.Equal(t, []string{"<135>", "<134>", "<132>", "<131>", "<129>", "<128>"}, priorities)
+		assert.Equal(t, 2, syslogSeverity(DPanicLevel))
+	})
+
+	t.Run("rfc3164", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{Format: RFC3164, Facility: SyslogDaemon})
+
+		logger.Warn("disk almost full", xfield.Int("percent", 91))
+		logger.Warn("no fields")
+
+		assert.Equal(t, []string{
+			`<28>Jan  2 03:04:05 host app[42]: disk almost full [xlog@32473 percent="91"]`,
+			`<28>Jan  2 03:04:05 host app[42]: no fields`,
+		}, out.messages)
+	})
+
+	t.Run("escapes line breaks", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{})
+		rfc3164, out3164 := initSyslogLogger(t, SyslogConfig{Format: RFC3164})
+
+		logger.Info("line1\nline2\r\n", xfield.String("body", "a\nb"))
+		rfc3164.Info("line1\nline2")
+
+		assert.Equal(t, []string{
+			`<14>1 2024-01-02T03:04:05.000006Z host app 42 - [xlog@32473 body="a\nb"] line1\nline2\r\n`,
+		}, out.messages)
+		assert.Equal(t, []string{`<14>Jan  2 03:04:05 host app[42]: line1\nline2`}, out3164.messages)
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{Body: SyslogJSON})
+
+		logger.Named("db").With(xfield.String("service", "api")).Error("query failed", xfield.Group("query", xfield.Int("rows", 0)))
+
+		require.Len(t, out.messages, 1)
+		assert.Equal(t,
+			`<11>1 2024-01-02T03:04:05.000006Z host app 42 - - {"level":"error","logger":"db","msg":"query failed","service":"api","query":{"rows":0}}`,
+			out.messages[0],
+		)
+	})
+
+	t.Run("json body rfc3164", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{Format: RFC3164, Body: SyslogJSON})
+
+		logger.Info("hello", xfield.Int("n", 1))
+
+		assert.Equal(t, []string{`<14>Jan  2 03:04:05 host app[42]: {"level":"info","msg":"hello","n":1}`}, out.messages)
+	})
+
+	t.Run("sanitizes header and names", func(t *testing.T) {
+		logger, out := initSyslogLogger(t, SyslogConfig{
+			Hostname: "my host",
+			AppName:  strings.Repeat("a", 60),
+			SDID:     "bad id=1",
+		})
+
+		logger.Info("m", xfield.String(`k"e]y= `+strings.Repeat("x", 40), "bad\xffutf8"))
+
+		require.Len(t, out.messages, 1)
+		assert.Equal(t,
+			`<14>1 2024-01-02T03:04:05.000006Z my_host `+strings.Repeat("a", 48)+` 42 - [bad_id_1 k_e_y__`+strings.Repeat("x", 25)+`="bad`+"�"+`utf8"] m`,
+			out.messages[0],
+		)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		out := &recordingWriter{}
+		NewSyslogLogger(out, SyslogConfig{}, WithClock(func() time.Time { return testTime })).Info("m")
+
+		require.Len(t, out.messages, 1)
+		assert.True(t, strings.HasPrefix(out.messages[0], "<14>1 2024-01-02T03:04:05.000006Z "))
+		assert.True(t, strings.HasSuffix(out.messages[0], " "+strconv.Itoa(os.Getpid())+" - - m"), out.messages[0])
+	})
+}
+
+func TestSyslogLoggerOverNetwork(t *testing.T) {
+	t.Run("udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := sink.NewNetwork("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer w.Close()
+
+		logger := NewSyslogLogger(w, SyslogConfig{Hostname: "host", AppName: "app", ProcID: "1"},
+			WithClock(func() time.Time { return testTime }))
+		logger.Info("first", xfield.Int("n", 1))
+		logger.Info("second")
+
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		for _, want := range []string{
+			`<14>1 2024-01-02T03:04:05.000006Z host app 1 - [xlog@32473 n="1"] first`,
+			`<14>1 2024-01-02T03:04:05.000006Z host app 1 - - second`,
+		} {
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(buf[:n]))
+		}
+	})
+
+	t.Run("tcp with octet counting", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		received := make(chan string, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			data, _ := io.ReadAll(conn)
+			received <- string(data)
+		}()
+
+		w, err := sink.NewNetwork("tcp", ln.Addr().String())
+		require.NoError(t, err)
+
+		logger := NewSyslogLogger(w, SyslogConfig{Hostname: "host", AppName: "app", ProcID: "1"},
+			WithClock(func() time.Time { return testTime }))
+		logger.Info("multi\nline")
+		logger.Info("second")
+		require.NoError(t, w.Close())
+
+		var messages []string
+		reader := bufio.NewReader(bytes.NewBufferString(<-received))
+		for {
+			length, err := reader.ReadString(' ')
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			require.NoError(t, err)
+			msg := make([]byte, n)
+			_, err = io.ReadFull(reader, msg)
+			require.NoError(t, err)
+			messages = append(messages, string(msg))
+		}
+
+		assert.Equal(t, []string{
+			"<14>1 2024-01-02T03:04:05.000006Z host app 1 - - multi\\nline",
+			"<14>1 2024-01-02T03:04:05.000006Z host app 1 - - second",
+		}, messages)
+	})
+}
@@ -2,12 +2,17 @@ package xlog
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/ruko1202/xlog/xfield"
 )
@@ -18,12 +23,13 @@ type loggerCalls struct {
 }
 
 var loggers = map[zapcore.Level]*loggerCalls{
-	zapcore.DebugLevel: {log: Debug, logf: Debugf},
-	zapcore.InfoLevel:  {log: Info, logf: Infof},
-	zapcore.WarnLevel:  {log: Warn, logf: Warnf},
-	zapcore.ErrorLevel: {log: Error, logf: Errorf},
-	zapcore.FatalLevel: {log: Fatal, logf: Fatalf},
-	zapcore.PanicLevel: {log: Panic, logf: Panicf},
+	zapcore.DebugLevel:  {log: Debug, logf: Debugf},
+	zapcore.InfoLevel:   {log: Info, logf: Infof},
+	zapcore.WarnLevel:   {log: Warn, logf: Warnf},
+	zapcore.ErrorLevel:  {log: Error, logf: Errorf},
+	zapcore.DPanicLevel: {log: DPanic, logf: DPanicf},
+	zapcore.FatalLevel:  {log: Fatal, logf: Fatalf},
+	zapcore.PanicLevel:  {log: Panic, logf: Panicf},
 }
 
 func TestLogger(t *testing.T) {
@@ -70,6 +76,29 @@ func testLogger(t *testing.T, level zapcore.Level, calls *loggerCalls) {
 	})
 }
 
+func TestHelpersCallerSkip(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	// one frame for the helper and one for the adapter
+	logger := NewZapAdapter(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2)))
+	ctx := ContextWithLogger(context.Background(), logger)
+	t.Cleanup(ReplaceStacktraceLevel(ErrorLevel))
+
+	for level, calls := range loggers {
+		if level >= zapcore.PanicLevel {
+			continue
+		}
+		calls.log(ctx, "message")
+		calls.logf(ctx, "message %d", 1)
+	}
+	Log(ctx, InfoLevel, "message")
+	Log(ctx, DPanicLevel, "message")
+
+	require.Equal(t, 12, logs.Len())
+	for _, entry := range logs.All() {
+		assert.True(t, strings.HasSuffix(entry.Caller.File, "/logger_test.go"), "%s: %s", entry.Level, entry.Caller)
+	}
+}
+
 func TestUseGlobalLogger(t *testing.T) {
 	logger, logs := initTestLogger(t)
 
@@ -82,3 +111,98 @@ func TestUseGlobalLogger(t *testing.T) {
 
 	require.Equal(t, 1, logs.Len())
 }
+
+func TestLog(t *testing.T) {
+	logger, logs := initTestLogger(t)
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	Log(ctx, WarnLevel, "warn", xfield.String("key", "value"))
+	Log(ctx, DebugLevel, "debug")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "value", entries[0].ContextMap()["key"])
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+}
+
+func TestReplaceDevelopment(t *testing.T) {
+	t.Run("dpanic panics in development only", func(t *testing.T) {
+		ctx := ContextWithLogger(context.Background(), NewNoopLogger())
+		assert.False(t, IsDevelopment())
+		assert.NotPanics(t, func() { DPanic(ctx, "unexpected") })
+
+		restore := ReplaceDevelopment(true)
+		t.Cleanup(restore)
+
+		assert.True(t, IsDevelopment())
+		assert.PanicsWithValue(t, "unexpected", func() { DPanic(ctx, "unexpected") })
+		assert.PanicsWithValue(t, "unexpected 1", func() { DPanicf(ctx, "unexpected %d", 1) })
+
+		restore()
+		assert.False(t, IsDevelopment())
+	})
+
+	t.Run("zap loggers follow their own option", func(t *testing.T) {
+		t.Cleanup(ReplaceDevelopment(true))
+
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+		assert.NotPanics(t, func() { DPanic(ctx, "unexpected") })
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, zapcore.DPanicLevel, logs.All()[0].Level)
+	})
+}
+
+func TestReplaceStacktraceLevel(t *testing.T) {
+	t.Run("adds the stack of the caller at or above the level", func(t *testing.T) {
+		restore := ReplaceStacktraceLevel(ErrorLevel)
+		t.Cleanup(restore)
+
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		Warn(ctx, "warn")
+		Error(ctx, "error")
+		Errorf(ctx, "error %d", 2)
+
+		entries := logs.All()
+		require.Len(t, entries, 3)
+		assert.NotContains(t, entries[0].ContextMap(), "stacktrace")
+		for _, entry := range entries[1:] {
+			stack, ok := entry.ContextMap()["stacktrace"].(string)
+			require.True(t, ok)
+			assert.Regexp(t, `^github\.com/ruko1202/xlog\.TestReplaceStacktraceLevel\.func\d+\n\t.+/logger_test\.go:\d+\n`, stack)
+		}
+	})
+
+	t.Run("restores the previous level", func(t *testing.T) {
+		ReplaceStacktraceLevel(WarnLevel)()
+
+		logger, logs := initTestLogger(t)
+		Error(ContextWithLogger(context.Background(), logger), "error")
+
+		require.Equal(t, 1, logs.Len())
+		assert.NotContains(t, logs.All()[0].ContextMap(), "stacktrace")
+	})
+
+	t.Run("span error reuses the stack", func(t *testing.T) {
+		restore := ReplaceStacktraceLevel(ErrorLevel)
+		t.Cleanup(restore)
+		spanRecorder := setupTestTracer(t)
+
+		logger, logs := initTestLogger(t)
+		ctx, span := WithOperationSpan(ContextWithLogger(context.Background(), logger), "test")
+		Error(ctx, "failed", xfield.Error(errors.New("boom")))
+		span.End()
+
+		require.Equal(t, 1, logs.Len())
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		require.Len(t, spans[0].Events(), 1)
+		event := attribute.NewSet(spans[0].Events()[0].Attributes...)
+		stack, ok := event.Value("exception.stacktrace")
+		require.True(t, ok)
+		assert.Equal(t, logs.All()[0].ContextMap()["stacktrace"], stack.AsString())
+	})
+}
@@ -0,0 +1,164 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// ErrMessageTooLarge is returned by GELFUDP.Write when a message doesn't fit in 128 chunks.
+var ErrMessageTooLarge = errors.New("sink: GELF message exceeds 128 chunks")
+
+const (
+	// DefaultGELFChunkSize is the default size of a UDP datagram, leaving room for the
+	// IP and UDP headers within a typical 1500 bytes MTU.
+	DefaultGELFChunkSize = 1420
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+	gelfMinChunkSize    = gelfChunkHeaderSize + 1
+)
+
+// GELFOption is a function that configures a GELFUDP.
+type GELFOption func(*gelfOptions)
+
+type gelfOptions struct {
+	chunkSize int
+	compress  bool
+}
+
+// WithChunkSize sets the maximum size of a datagram. Larger messages are split into chunks.
+// The default is DefaultGELFChunkSize.
+func WithChunkSize(size int) GELFOption {
+	return func(o *gelfOptions) {
+		o.chunkSize = max(size, gelfMinChunkSize)
+	}
+}
+
+// WithGzip compresses messages with gzip before chunking them.
+func WithGzip() GELFOption {
+	return func(o *gelfOptions) {
+		o.compress = true
+	}
+}
+
+// GELFUDP is an io.WriteCloser sending each Write as one GELF message over UDP.
+// Messages larger than the chunk size are split into GELF chunks, at most 128 per message.
+// Over TCP GELF messages aren't chunked or compressed: use Network with NullByte framing.
+//
+// Example:
+//
+//	conn, err := sink.NewGELFUDP("graylog.internal:12201", sink.WithGzip())
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	logger := xlog.NewGELFLogger(conn, xlog.GELFConfig{})
+type GELFUDP struct {
+	opts *gelfOptions
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  bytes.Buffer
+	gz   *gzip.Writer
+}
+
+// NewGELFUDP creates a GELFUDP sending to the address.
+func NewGELFUDP(address string, options ...GELFOption) (*GELFUDP, error) {
+	opts := &gelfOptions{
+		chunkSize: DefaultGELFChunkSize,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("dial udp %s: %w", address, err)
+	}
+	return &GELFUDP{opts: opts, conn: conn}, nil
+}
+
+// Write sends p as one message, compressed and chunked as configured.
+func (g *GELFUDP) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	msg := p
+	if g.opts.compress {
+		compressed, err := g.compress(p)
+		if err != nil {
+			return 0, err
+		}
+		msg = compressed
+	}
+
+	if len(msg) <= g.opts.chunkSize {
+		if _, err := g.conn.Write(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if err := g.writeChunks(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync does nothing: messages are sent by Write. It lets GELFUDP be used as a zapcore.WriteSyncer.
+func (g *GELFUDP) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (g *GELFUDP) Close() error {
+	return g.conn.Close()
+}
+
+func (g *GELFUDP) compress(p []byte) ([]byte, error) {
+	g.buf.Reset()
+	if g.gz == nil {
+		g.gz = gzip.NewWriter(&g.buf)
+	} else {
+		g.gz.Reset(&g.buf)
+	}
+	if _, err := g.gz.Write(p); err != nil {
+		return nil, err
+	}
+	if err := g.gz.Close(); err != nil {
+		return nil, err
+	}
+	return g.buf.Bytes(), nil
+}
+
+// writeChunks sends the message as chunks sharing a random message ID:
+// magic bytes 0x1e 0x0f, 8 bytes message ID, sequence number, sequence count, payload.
+func (g *GELFUDP) writeChunks(msg []byte) error {
+	payloadSize := g.opts.chunkSize - gelfChunkHeaderSize
+	count := (len(msg) + payloadSize - 1) / payloadSize
+	if count > gelfMaxChunks {
+		return ErrMessageTooLarge
+	}
+
+	chunk := make([]byte, 0, g.opts.chunkSize)
+	chunk = append(chunk, 0x1e, 0x0f)
+	chunk = append(chunk, make([]byte, 8)...)
+	_, _ = rand.Read(chunk[2:10]) // never fails
+	chunk = append(chunk, 0, byte(count))
+
+	for seq := 0; seq < count; seq++ {
+		payload := msg[seq*payloadSize : min((seq+1)*payloadSize, len(msg))]
+		chunk = chunk[:gelfChunkHeaderSize]
+		chunk[10] = byte(seq)
+		chunk = append(chunk, payload...)
+		if _, err := g.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
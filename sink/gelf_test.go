@@ -0,0 +1,109 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readDatagrams(t *testing.T, conn net.PacketConn, count int) [][]byte {
+	t.Helper()
+
+	var datagrams [][]byte
+	buf := make([]byte, 2048)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for i := 0; i < count; i++ {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		datagrams = append(datagrams, append([]byte(nil), buf[:n]...))
+	}
+	return datagrams
+}
+
+func TestGELFUDP(t *testing.T) {
+	t.Run("small messages are sent as is", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		g, err := NewGELFUDP(conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer g.Close()
+
+		written, err := g.Write([]byte(`{"short_message":"hello"}`))
+		require.NoError(t, err)
+		assert.Equal(t, 25, written)
+		require.NoError(t, g.Sync())
+
+		assert.Equal(t, [][]byte{[]byte(`{"short_message":"hello"}`)}, readDatagrams(t, conn, 1))
+	})
+
+	t.Run("large messages are chunked", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		g, err := NewGELFUDP(conn.LocalAddr().String(), WithChunkSize(20))
+		require.NoError(t, err)
+		defer g.Close()
+
+		msg := []byte("0123456789abcdefghijkl")
+		written, err := g.Write(msg)
+		require.NoError(t, err)
+		assert.Equal(t, len(msg), written)
+
+		chunks := readDatagrams(t, conn, 3)
+		var payload []byte
+		for seq, chunk := range chunks {
+			assert.Equal(t, []byte{0x1e, 0x0f}, chunk[:2])
+			assert.Equal(t, chunks[0][2:10], chunk[2:10], "chunks share the message ID")
+			assert.Equal(t, []byte{byte(seq), 3}, chunk[10:12])
+			payload = append(payload, chunk[12:]...)
+		}
+		assert.Equal(t, msg, payload)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		g, err := NewGELFUDP(conn.LocalAddr().String(), WithGzip())
+		require.NoError(t, err)
+		defer g.Close()
+
+		for _, msg := range []string{"first", "second"} {
+			_, err = g.Write([]byte(msg))
+			require.NoError(t, err)
+		}
+
+		for i, datagram := range readDatagrams(t, conn, 2) {
+			reader, err := gzip.NewReader(bytes.NewReader(datagram))
+			require.NoError(t, err)
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first", "second"}[i], string(data))
+		}
+	})
+
+	t.Run("rejects messages over 128 chunks", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		g, err := NewGELFUDP(conn.LocalAddr().String(), WithChunkSize(13))
+		require.NoError(t, err)
+		defer g.Close()
+
+		_, err = g.Write(bytes.Repeat([]byte("x"), 128))
+		require.NoError(t, err)
+		_, err = g.Write(bytes.Repeat([]byte("x"), 129))
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	})
+}
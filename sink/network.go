@@ -0,0 +1,284 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrReconnectBackoff is returned by Network.Write while it waits before the next reconnection attempt
+// or while another write is reconnecting.
+var ErrReconnectBackoff = errors.New("sink: waiting to reconnect")
+
+// Framing is how messages are delimited on stream connections (TCP, unix stream sockets),
+// as described in RFC 6587 for syslog. Datagram connections send one message per packet.
+type Framing int
+
+const (
+	// OctetCounting prefixes each message with its length and a space.
+	OctetCounting Framing = iota
+	// NonTransparent terminates each message with a newline.
+	NonTransparent
+	// NullByte terminates each message with a zero byte, as GELF over TCP.
+	NullByte
+)
+
+const (
+	defaultDialTimeout  = 5 * time.Second
+	defaultWriteTimeout = 5 * time.Second
+	defaultMinBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff   = 30 * time.Second
+)
+
+// NetworkOption is a function that configures a Network.
+type NetworkOption func(*networkOptions)
+
+type networkOptions struct {
+	framing      Framing
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	clock        func() time.Time
+}
+
+// WithFraming sets the framing of messages on stream connections. The default is OctetCounting.
+func WithFraming(framing Framing) NetworkOption {
+	return func(o *networkOptions) {
+		o.framing = framing
+	}
+}
+
+// WithDialTimeout sets the timeout of connection attempts. The default is 5 seconds.
+func WithDialTimeout(timeout time.Duration) NetworkOption {
+	return func(o *networkOptions) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the timeout of a single write. The default is 5 seconds.
+func WithWriteTimeout(timeout time.Duration) NetworkOption {
+	return func(o *networkOptions) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithBackoff sets the delay before reconnecting after a failure. The delay starts at minDelay
+// and doubles after every failed attempt up to maxDelay. The default is 100ms to 30s.
+func WithBackoff(minDelay, maxDelay time.Duration) NetworkOption {
+	return func(o *networkOptions) {
+		o.minBackoff = minDelay
+		o.maxBackoff = maxDelay
+	}
+}
+
+// WithNetworkClock sets the function returning the current time (for testing).
+func WithNetworkClock(clock func() time.Time) NetworkOption {
+	return func(o *networkOptions) {
+		o.clock = clock
+	}
+}
+
+// Network is an io.WriteCloser sending each Write as one message over UDP, TCP or a unix socket.
+// On stream connections messages are framed (see Framing). When the connection fails,
+// the message is retried once on a new connection; if that fails too, Write returns an
+// error and reconnection is attempted again after an exponential backoff, during which
+// writes fail fast with ErrReconnectBackoff. The backoff is reset once a message is sent,
+// and writes made while another one is reconnecting fail fast as well.
+//
+// Example:
+//
+//	conn, err := sink.NewNetwork("tcp", "syslog.internal:6514")
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	logger := xlog.NewSyslogLogger(conn, xlog.SyslogConfig{AppName: "api"})
+type Network struct {
+	network string
+	address string
+	opts    *networkOptions
+
+	mu          sync.Mutex
+	conn        net.Conn
+	backoff     time.Duration
+	nextAttempt time.Time
+	dialing     bool
+	closed      bool
+	frame       []byte
+}
+
+// NewNetwork connects to the address on the named network ("udp", "tcp", "unix", "unixgram", ...).
+func NewNetwork(network, address string, options ...NetworkOption) (*Network, error) {
+	opts := &networkOptions{
+		framing:      OctetCounting,
+		dialTimeout:  defaultDialTimeout,
+		writeTimeout: defaultWriteTimeout,
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		clock:        time.Now,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	n := &Network{
+		network: network,
+		address: address,
+		opts:    opts,
+	}
+	conn, err := n.dial()
+	if err != nil {
+		return nil, err
+	}
+	n.conn = conn
+	return n, nil
+}
+
+// Write sends p as one message.
+func (n *Network) Write(p []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return 0, net.ErrClosed
+	}
+
+	if n.conn == nil {
+		if n.dialing || n.opts.clock().Before(n.nextAttempt) {
+			return 0, ErrReconnectBackoff
+		}
+		if err := n.reconnect(); err != nil {
+			return 0, err
+		}
+	}
+
+	err := n.send(p)
+	if err != nil {
+		// the peer may have closed an idle connection, retry once on a new one
+		if err := n.reconnect(); err != nil {
+			return 0, err
+		}
+		if err := n.send(p); err != nil {
+			n.fail()
+			return 0, err
+		}
+	}
+	// only a delivered message proves the peer is back: a peer accepting connections
+	// and resetting them must keep the backoff growing
+	n.backoff = 0
+	return len(p), nil
+}
+
+// Sync does nothing: messages are sent by Write. It lets Network be used as a zapcore.WriteSyncer.
+func (n *Network) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (n *Network) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return nil
+	}
+	n.closed = true
+	if n.conn == nil {
+		return nil
+	}
+	return n.conn.Close()
+}
+
+func (n *Network) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout(n.network, n.address, n.opts.dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s %s: %w", n.network, n.address, err)
+	}
+	return conn, nil
+}
+
+// reconnect replaces the connection. It must be called with mu held, which it releases
+// during the dial: the other writes fail fast with ErrReconnectBackoff instead of waiting
+// for the dial timeout.
+func (n *Network) reconnect() error {
+	if n.conn != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+
+	n.dialing = true
+	n.mu.Unlock()
+	conn, err := n.dial()
+	n.mu.Lock()
+	n.dialing = false
+
+	switch {
+	case n.closed:
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return net.ErrClosed
+	case err != nil:
+		n.fail()
+		return err
+	}
+	n.conn = conn
+	return nil
+}
+
+// fail drops the connection and schedules the next attempt.
+func (n *Network) fail() {
+	if n.conn != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+
+	switch {
+	case n.backoff == 0:
+		n.backoff = n.opts.minBackoff
+	case n.backoff < n.opts.maxBackoff:
+		n.backoff = min(2*n.backoff, n.opts.maxBackoff)
+	}
+	n.nextAttempt = n.opts.clock().Add(n.backoff)
+}
+
+func (n *Network) send(p []byte) error {
+	if n.opts.writeTimeout > 0 {
+		_ = n.conn.SetWriteDeadline(time.Now().Add(n.opts.writeTimeout))
+	}
+
+	if !n.isStream() {
+		_, err := n.conn.Write(p)
+		return err
+	}
+
+	// frame the message in a single write, so concurrent writers can't interleave
+	n.frame = n.frame[:0]
+	switch n.opts.framing {
+	case NonTransparent:
+		n.frame = append(n.frame, p...)
+		n.frame = append(n.frame, '\n')
+	case NullByte:
+		n.frame = append(n.frame, p...)
+		n.frame = append(n.frame, 0)
+	default:
+		n.frame = strconv.AppendInt(n.frame, int64(len(p)), 10)
+		n.frame = append(n.frame, ' ')
+		n.frame = append(n.frame, p...)
+	}
+	_, err := n.conn.Write(n.frame)
+	return err
+}
+
+func (n *Network) isStream() bool {
+	switch n.network {
+	case "udp", "udp4", "udp6", "unixgram", "unixpacket":
+		return false
+	default:
+		return true
+	}
+}
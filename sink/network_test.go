@@ -0,0 +1,283 @@
+package sink
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptAll accepts connections one at a time and sends everything each of them sent.
+func acceptAll(t *testing.T, ln net.Listener) <-chan string {
+	t.Helper()
+
+	received := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				close(received)
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			_ = conn.Close()
+			received <- string(data)
+		}
+	}()
+	return received
+}
+
+func TestNetwork(t *testing.T) {
+	t.Run("udp sends one datagram per write", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		n, err := NewNetwork("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer n.Close()
+
+		for _, msg := range []string{"first", "second"} {
+			written, err := n.Write([]byte(msg))
+			require.NoError(t, err)
+			assert.Equal(t, len(msg), written)
+		}
+
+		buf := make([]byte, 64)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		for _, want := range []string{"first", "second"} {
+			size, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(buf[:size]))
+		}
+	})
+
+	t.Run("tcp framing", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			framing Framing
+			want    string
+		}{
+			"octet counting":  {framing: OctetCounting, want: "5 first6 multi\n"},
+			"non-transparent": {framing: NonTransparent, want: "first\nmulti\n\n"},
+			"null byte":       {framing: NullByte, want: "first\x00multi\n\x00"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				defer ln.Close()
+				received := acceptAll(t, ln)
+
+				n, err := NewNetwork("tcp", ln.Addr().String(), WithFraming(tc.framing))
+				require.NoError(t, err)
+				_, err = n.Write([]byte("first"))
+				require.NoError(t, err)
+				_, err = n.Write([]byte("multi\n"))
+				require.NoError(t, err)
+				require.NoError(t, n.Sync())
+				require.NoError(t, n.Close())
+				require.NoError(t, n.Close())
+
+				assert.Equal(t, tc.want, <-received)
+				_, err = n.Write([]byte("closed"))
+				assert.ErrorIs(t, err, net.ErrClosed)
+			})
+		}
+	})
+
+	t.Run("unix stream socket", func(t *testing.T) {
+		ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "log.sock"))
+		require.NoError(t, err)
+		defer ln.Close()
+		received := acceptAll(t, ln)
+
+		n, err := NewNetwork("unix", ln.Addr().String())
+		require.NoError(t, err)
+		_, err = n.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, n.Close())
+
+		assert.Equal(t, "5 hello", <-received)
+	})
+
+	t.Run("unix packet socket sends one unframed message per write", func(t *testing.T) {
+		ln, err := net.Listen("unixpacket", filepath.Join(t.TempDir(), "log.sock"))
+		if err != nil {
+			t.Skip("unixpacket is not supported:", err)
+		}
+		defer ln.Close()
+
+		n, err := NewNetwork("unixpacket", ln.Addr().String())
+		require.NoError(t, err)
+		defer n.Close()
+		_, err = n.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+		buf := make([]byte, 64)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		size, err := conn.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(buf[:size]))
+	})
+
+	t.Run("dial error", func(t *testing.T) {
+		_, err := NewNetwork("tcp", "127.0.0.1:1", WithDialTimeout(time.Second))
+		assert.ErrorContains(t, err, "dial tcp 127.0.0.1:1")
+	})
+
+	t.Run("reconnects after the peer closes the connection", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		accepted := make(chan net.Conn, 2)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				accepted <- conn
+			}
+		}()
+
+		n, err := NewNetwork("tcp", ln.Addr().String(), WithFraming(NonTransparent))
+		require.NoError(t, err)
+		defer n.Close()
+
+		first := <-accepted
+		require.NoError(t, first.Close())
+
+		// the first write after the peer closed may still succeed locally
+		require.Eventually(t, func() bool {
+			_, err := n.Write([]byte("retry"))
+			require.NoError(t, err)
+			select {
+			case second := <-accepted:
+				line, err := bufio.NewReader(second).ReadString('\n')
+				require.NoError(t, err)
+				assert.Equal(t, "retry\n", line)
+				_ = second.Close()
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("backs off between reconnection attempts", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		n, err := NewNetwork("tcp", addr,
+			WithBackoff(time.Second, 4*time.Second),
+			WithNetworkClock(func() time.Time { return now }),
+		)
+		require.NoError(t, err)
+		defer n.Close()
+
+		// the collector goes away, so writes fail on the connection and on the redial
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		require.NoError(t, ln.Close())
+		require.Eventually(t, func() bool {
+			_, err := n.Write([]byte("lost"))
+			return err != nil
+		}, time.Second, time.Millisecond)
+
+		_, err = n.Write([]byte("lost"))
+		assert.ErrorIs(t, err, ErrReconnectBackoff)
+		assert.Equal(t, time.Second, n.backoff)
+
+		now = now.Add(time.Second)
+		_, err = n.Write([]byte("lost"))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrReconnectBackoff)
+		assert.Equal(t, 2*time.Second, n.backoff)
+
+		now = now.Add(2 * time.Second)
+		_, _ = n.Write([]byte("lost"))
+		now = now.Add(4 * time.Second)
+		_, _ = n.Write([]byte("lost"))
+		assert.Equal(t, 4*time.Second, n.backoff)
+
+		// the collector is back
+		ln, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		defer ln.Close()
+		received := acceptAll(t, ln)
+
+		now = now.Add(4 * time.Second)
+		_, err = n.Write([]byte("back"))
+		require.NoError(t, err)
+		assert.Zero(t, n.backoff)
+		require.NoError(t, n.Close())
+		assert.Equal(t, "4 back", <-received)
+	})
+
+	t.Run("keeps backing off when the peer accepts but writes fail", func(t *testing.T) {
+		ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "log.sock"))
+		require.NoError(t, err)
+		defer ln.Close()
+
+		// the peer accepts connections but never reads, so large messages time out
+		held := make(chan net.Conn, 16)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				held <- conn
+			}
+		}()
+
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		n, err := NewNetwork("unix", ln.Addr().String(),
+			WithWriteTimeout(10*time.Millisecond),
+			WithBackoff(time.Second, 8*time.Second),
+			WithNetworkClock(func() time.Time { return now }),
+		)
+		require.NoError(t, err)
+		defer n.Close()
+
+		message := make([]byte, 8<<20)
+		for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			_, err = n.Write(message)
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrReconnectBackoff)
+			assert.Equal(t, backoff, n.backoff)
+			now = now.Add(backoff)
+		}
+	})
+
+	t.Run("writes fail fast while another one reconnects", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		acceptAll(t, ln)
+
+		n, err := NewNetwork("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		defer n.Close()
+
+		n.mu.Lock()
+		_ = n.conn.Close()
+		n.conn = nil
+		n.dialing = true
+		n.mu.Unlock()
+
+		_, err = n.Write([]byte("dropped"))
+		assert.ErrorIs(t, err, ErrReconnectBackoff)
+	})
+}
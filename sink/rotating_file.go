@@ -0,0 +1,462 @@
+// Package sink provides writers the native loggers and zap can write log entries to.
+package sink
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// backupTimeFormat is the layout of the timestamp in the names of rotated files.
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+
+	defaultMaxSize = 100 << 20
+)
+
+// RotatingFileOption is a function that configures a RotatingFile.
+type RotatingFileOption func(*rotatingFileOptions)
+
+type rotatingFileOptions struct {
+	maxSize     int64
+	interval    time.Duration
+	maxAge      time.Duration
+	maxBackups  int
+	compress    bool
+	localTime   bool
+	reopenOnHUP bool
+	clock       func() time.Time
+}
+
+// WithMaxSize sets the size in bytes the file is rotated at. The default is 100 MiB.
+// Zero or a negative size disables size-based rotation.
+func WithMaxSize(size int64) RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.maxSize = size
+	}
+}
+
+// WithRotationInterval rotates the file once it has been written to for the given duration.
+// By default the file is rotated by size only.
+func WithRotationInterval(interval time.Duration) RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.interval = interval
+	}
+}
+
+// WithMaxAge removes rotated files older than the given duration.
+// By default rotated files are not removed based on their age.
+func WithMaxAge(age time.Duration) RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.maxAge = age
+	}
+}
+
+// WithMaxBackups sets the maximum number of rotated files to keep.
+// By default all rotated files are kept (subject to WithMaxAge).
+func WithMaxBackups(n int) RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.maxBackups = n
+	}
+}
+
+// WithCompress compresses rotated files with gzip.
+func WithCompress() RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.compress = true
+	}
+}
+
+// WithLocalTime names rotated files using the local time instead of UTC.
+func WithLocalTime() RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.localTime = true
+	}
+}
+
+// WithReopenOnSIGHUP reopens the file when the process receives SIGHUP,
+// so external tools such as logrotate can move it away.
+func WithReopenOnSIGHUP() RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.reopenOnHUP = true
+	}
+}
+
+// WithClock sets the function returning the current time (for testing).
+func WithClock(clock func() time.Time) RotatingFileOption {
+	return func(o *rotatingFileOptions) {
+		o.clock = clock
+	}
+}
+
+// RotatingFile is an io.WriteCloser writing to a file that is rotated by size and/or time.
+// Rotated files are renamed to "<name>-<timestamp><ext>" in the same directory,
+// e.g. "app-2024-01-02T03-04-05.000.log", and optionally compressed. Files rotated within
+// the same millisecond get a "-1", "-2"... suffix after the timestamp.
+// Compression and removal of old files happen in the background.
+//
+// RotatingFile implements Sync, so it can be used as a zapcore.WriteSyncer,
+// and Logger.Sync of the native loggers flushes it.
+//
+// Example:
+//
+//	file, err := sink.NewRotatingFile("/var/log/app/app.log",
+//		sink.WithMaxSize(50<<20),
+//		sink.WithMaxBackups(5),
+//		sink.WithCompress(),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer file.Close()
+//	logger := xlog.NewJSONLogger(file)
+type RotatingFile struct {
+	path string
+	opts *rotatingFileOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	millCh  chan struct{}
+	signals chan os.Signal
+	wg      sync.WaitGroup
+}
+
+// NewRotatingFile opens the file at path for appending, creating it and its directory if needed.
+func NewRotatingFile(path string, options ...RotatingFileOption) (*RotatingFile, error) {
+	opts := &rotatingFileOptions{
+		maxSize: defaultMaxSize,
+		clock:   time.Now,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	f := &RotatingFile{
+		path:   path,
+		opts:   opts,
+		millCh: make(chan struct{}, 1),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	f.wg.Add(1)
+	go f.millRun()
+
+	if opts.reopenOnHUP {
+		f.signals = make(chan os.Signal, 1)
+		signal.Notify(f.signals, syscall.SIGHUP)
+		f.wg.Add(1)
+		go f.signalRun()
+	}
+
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if p doesn't fit
+// or the rotation interval has elapsed. If the rotation fails, p is still written
+// to the current file and the rotation error is returned.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	var rotateErr error
+	if f.shouldRotate(int64(len(p))) {
+		rotateErr = f.rotate()
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Sync commits the content of the file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Rotate closes the file, renames it with the current timestamp and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file at the path, after it has been moved by an external tool.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", f.path, err)
+	}
+	return f.open()
+}
+
+// Close closes the file and waits for the background compression and cleanup to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	err := f.file.Close()
+	f.mu.Unlock()
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+	}
+	close(f.millCh)
+	f.wg.Wait()
+
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.maxSize > 0 && f.size+n > f.opts.maxSize {
+		return true
+	}
+	return f.opts.interval > 0 && f.opts.clock().Sub(f.openedAt) >= f.opts.interval
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return fmt.Errorf("create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat %s: %w", f.path, err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.opts.clock()
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	// on failure, keep writing to the current file rather than losing all further entries
+	if err := f.file.Close(); err != nil {
+		return errors.Join(fmt.Errorf("close %s: %w", f.path, err), f.open())
+	}
+	if err := os.Rename(f.path, f.backupName(f.opts.clock())); err != nil {
+		return errors.Join(fmt.Errorf("rotate %s: %w", f.path, err), f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	select {
+	case f.millCh <- struct{}{}:
+	default: // a run is already pending
+	}
+	return nil
+}
+
+// backupName returns the name of the file rotated at t, with a "-1", "-2"... suffix
+// if a file rotated within the same millisecond exists, compressed or not.
+func (f *RotatingFile) backupName(t time.Time) string {
+	if !f.opts.localTime {
+		t = t.UTC()
+	}
+	dir, prefix, ext := f.nameParts()
+	stamp := prefix + t.Format(backupTimeFormat)
+	for seq := 0; ; seq++ {
+		name := stamp + ext
+		if seq > 0 {
+			name = stamp + "-" + strconv.Itoa(seq) + ext
+		}
+		name = filepath.Join(dir, name)
+		if !fileExists(name) && !fileExists(name+compressSuffix) {
+			return name
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir, name := filepath.Split(f.path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+func (f *RotatingFile) signalRun() {
+	defer f.wg.Done()
+	for range f.signals {
+		_ = f.Reopen()
+	}
+}
+
+func (f *RotatingFile) millRun() {
+	defer f.wg.Done()
+	for range f.millCh {
+		_ = f.mill()
+	}
+}
+
+// backup is a rotated file.
+type backup struct {
+	path       string
+	time       time.Time
+	seq        int // the collision suffix, 0 if none
+	compressed bool
+}
+
+// mill removes rotated files exceeding the limits and compresses the remaining ones.
+func (f *RotatingFile) mill() error {
+	if f.opts.maxBackups <= 0 && f.opts.maxAge <= 0 && !f.opts.compress {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	cutoff := f.opts.clock().Add(-f.opts.maxAge)
+	for i, b := range backups {
+		expired := f.opts.maxAge > 0 && b.time.Before(cutoff)
+		if expired || (f.opts.maxBackups > 0 && i >= f.opts.maxBackups) {
+			if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if f.opts.compress && !b.compressed {
+			errs = append(errs, compressFile(b.path, b.path+compressSuffix))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backups returns the rotated files, newest first.
+func (f *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read log directory: %w", err)
+	}
+
+	location := time.UTC
+	if f.opts.localTime {
+		location = time.Local
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		compressed := strings.HasSuffix(stamp, ext+compressSuffix)
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, compressSuffix), ext)
+
+		seq := 0
+		if len(stamp) > len(backupTimeFormat) && stamp[len(backupTimeFormat)] == '-' {
+			if seq, err = strconv.Atoi(stamp[len(backupTimeFormat)+1:]); err != nil || seq <= 0 {
+				continue
+			}
+			stamp = stamp[:len(backupTimeFormat)]
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, location)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t, seq: seq, compressed: compressed})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// compressFile compresses src into dst and removes src.
+func compressFile(src, dst string) (err error) {
+	in, err := os.Open(src) // #nosec G304 - src is a rotated file found in the log directory
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 - dst is derived from src
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return fmt.Errorf("compress %s: %w", src, err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("compress %s: %w", src, err)
+	}
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", dst, err)
+	}
+
+	_ = in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,296 @@
+package sink
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ruko1202/xlog"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("creates directory and appends to existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+		f, err := NewRotatingFile(path)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("first\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		f, err = NewRotatingFile(path)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("second\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, "first\nsecond\n", readFile(t, path))
+	})
+
+	t.Run("rotates by size", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		clock := newFakeClock()
+
+		f, err := NewRotatingFile(path, WithMaxSize(10), WithClock(clock.Now))
+		require.NoError(t, err)
+
+		_, err = f.Write([]byte("12345\n"))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("123\n"))
+		require.NoError(t, err)
+		clock.Advance(time.Second)
+		_, err = f.Write([]byte("next\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"app-2024-01-02T03-04-06.000.log", "app.log"}, listDir(t, dir))
+		assert.Equal(t, "12345\n123\n", readFile(t, filepath.Join(dir, "app-2024-01-02T03-04-06.000.log")))
+		assert.Equal(t, "next\n", readFile(t, path))
+	})
+
+	t.Run("oversized write goes to a fresh file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		f, err := NewRotatingFile(path, WithMaxSize(4))
+		require.NoError(t, err)
+
+		_, err = f.Write([]byte("too long\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"app.log"}, listDir(t, dir))
+		assert.Equal(t, "too long\n", readFile(t, path))
+	})
+
+	t.Run("rotates by interval", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		clock := newFakeClock()
+
+		f, err := NewRotatingFile(path, WithMaxSize(0), WithRotationInterval(time.Hour), WithClock(clock.Now))
+		require.NoError(t, err)
+
+		_, err = f.Write([]byte("a\n"))
+		require.NoError(t, err)
+		clock.Advance(59 * time.Minute)
+		_, err = f.Write([]byte("b\n"))
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+		_, err = f.Write([]byte("c\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"app-2024-01-02T04-04-05.000.log", "app.log"}, listDir(t, dir))
+		assert.Equal(t, "c\n", readFile(t, path))
+	})
+
+	t.Run("max backups and max age", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		clock := newFakeClock()
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app-2023-01-01T00-00-00.000.log"), nil, 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app-unrelated.log"), nil, 0o600))
+
+		f, err := NewRotatingFile(path, WithMaxBackups(2), WithMaxAge(24*time.Hour), WithClock(clock.Now))
+		require.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			clock.Advance(time.Second)
+			_, err = f.Write([]byte("entry\n"))
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{
+			"app-2024-01-02T03-04-08.000.log",
+			"app-2024-01-02T03-04-09.000.log",
+			"app-unrelated.log",
+			"app.log",
+		}, listDir(t, dir))
+	})
+
+	t.Run("compresses rotated files", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		f, err := NewRotatingFile(path, WithCompress(), WithClock(newFakeClock().Now))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("compressed\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Rotate())
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"app-2024-01-02T03-04-05.000.log.gz", "app.log"}, listDir(t, dir))
+
+		file, err := os.Open(filepath.Join(dir, "app-2024-01-02T03-04-05.000.log.gz"))
+		require.NoError(t, err)
+		defer file.Close()
+		gz, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		data, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, "compressed\n", string(data))
+	})
+
+	t.Run("utc and local time naming", func(t *testing.T) {
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("test", 3*60*60))
+
+		for name, tc := range map[string]struct {
+			options []RotatingFileOption
+			backup  string
+		}{
+			"utc":   {backup: "app-2024-01-02T00-04-05.000.log"},
+			"local": {options: []RotatingFileOption{WithLocalTime()}, backup: "app-2024-01-02T03-04-05.000.log"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				options := append([]RotatingFileOption{WithClock(func() time.Time { return now })}, tc.options...)
+
+				f, err := NewRotatingFile(filepath.Join(dir, "app.log"), options...)
+				require.NoError(t, err)
+				_, err = f.Write([]byte("entry\n"))
+				require.NoError(t, err)
+				require.NoError(t, f.Rotate())
+				require.NoError(t, f.Close())
+
+				assert.Equal(t, []string{tc.backup, "app.log"}, listDir(t, dir))
+			})
+		}
+	})
+
+	t.Run("rotations within the same millisecond get a suffix", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		clock := newFakeClock()
+
+		f, err := NewRotatingFile(path, WithClock(clock.Now), WithMaxBackups(3))
+		require.NoError(t, err)
+		for _, entry := range []string{"1\n", "2\n", "3\n", "4\n"} {
+			_, err = f.Write([]byte(entry))
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{
+			"app-2024-01-02T03-04-05.000-1.log",
+			"app-2024-01-02T03-04-05.000-2.log",
+			"app-2024-01-02T03-04-05.000-3.log",
+			"app.log",
+		}, listDir(t, dir), "the oldest backup is removed by max backups")
+		assert.Equal(t, "2\n", readFile(t, filepath.Join(dir, "app-2024-01-02T03-04-05.000-1.log")))
+		assert.Equal(t, "4\n", readFile(t, filepath.Join(dir, "app-2024-01-02T03-04-05.000-3.log")))
+	})
+
+	t.Run("writes the entry when rotation fails", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		f, err := NewRotatingFile(path, WithMaxSize(15))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("first entry\n"))
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(path)) // the rename of the rotation fails
+
+		n, err := f.Write([]byte("second\n"))
+		assert.Error(t, err)
+		assert.Equal(t, 7, n)
+		_, err = f.Write([]byte("third\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"app.log"}, listDir(t, dir))
+		assert.Equal(t, "second\nthird\n", readFile(t, path))
+	})
+
+	t.Run("closed file", func(t *testing.T) {
+		f, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.NoError(t, f.Close())
+
+		_, err = f.Write([]byte("x"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+		assert.ErrorIs(t, f.Rotate(), os.ErrClosed)
+		assert.ErrorIs(t, f.Reopen(), os.ErrClosed)
+		assert.NoError(t, f.Sync())
+	})
+
+	t.Run("works with loggers", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		f, err := NewRotatingFile(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		logger := xlog.NewJSONLogger(f, xlog.WithTimeKey(""))
+		logger.Info("native", xfield.Int("n", 1))
+		require.NoError(t, logger.Sync())
+
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), f, zapcore.InfoLevel)
+		zapLogger := xlog.NewZapAdapter(zap.New(core))
+		zapLogger.Info("zap")
+		require.NoError(t, zapLogger.Sync())
+
+		assert.Equal(t, "{\"level\":\"info\",\"msg\":\"native\",\"n\":1}\n{\"msg\":\"zap\"}\n", readFile(t, path))
+	})
+}
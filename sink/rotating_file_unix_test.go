@@ -0,0 +1,38 @@
+//go:build unix
+
+package sink
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileReopenOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(path, WithReopenOnSIGHUP())
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("before\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "moved.log")))
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = f.Write([]byte("after\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "before\n", readFile(t, filepath.Join(dir, "moved.log")))
+	assert.Equal(t, "after\n", readFile(t, path))
+}
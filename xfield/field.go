@@ -3,6 +3,7 @@ package xfield
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -36,6 +37,8 @@ const (
 	ObjectType
 	// BinaryType indicates a binary/byte slice field.
 	BinaryType
+	// GroupType indicates a group of nested fields.
+	GroupType
 )
 
 // Field represents a structured logging field with a key-value pair.
@@ -203,6 +206,13 @@ func Object(key string, val interface{}) Field {
 	return Field{Key: key, Type: ObjectType, Interface: val}
 }
 
+// Group creates a field holding nested fields under the key.
+// Backends render it as a nested object, or flatten it into dotted keys (e.g. "http.method")
+// where nesting isn't supported.
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, Type: GroupType, Interface: fields}
+}
+
 // FormatValue formats the field value as a string for display purposes.
 // This is primarily used for debugging and testing.
 func (f Field) FormatValue() string {
@@ -234,6 +244,20 @@ func (f Field) FormatValue() string {
 			return string(b)
 		}
 		return fmt.Sprintf("%v", f.Interface)
+	case GroupType:
+		fields, _ := f.Interface.([]Field)
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, nested := range fields {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(nested.Key)
+			b.WriteByte('=')
+			b.WriteString(nested.FormatValue())
+		}
+		b.WriteByte('}')
+		return b.String()
 	default:
 		return fmt.Sprintf("%v", f.Interface)
 	}
@@ -0,0 +1,96 @@
+package xfield
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Stack creates a string field with the stack of the current goroutine, starting at the caller.
+// Each frame is written as the function, then the file and line indented on the next line,
+// like zap's stacktrace.
+func Stack(key string) Field {
+	return String(key, takeStacktrace(0))
+}
+
+// StackSkip creates a stack field like Stack, skipping the given number of frames
+// above the caller. It is meant for logging helpers that shouldn't appear in the stack.
+func StackSkip(key string, skip int) Field {
+	return String(key, takeStacktrace(skip))
+}
+
+// Caller creates a string field with the location of the caller as "dir/file.go:line:pkg.Function".
+func Caller(key string) Field {
+	return String(key, takeCaller(0))
+}
+
+// CallerSkip creates a caller field like Caller, skipping the given number of frames above the caller.
+func CallerSkip(key string, skip int) Field {
+	return String(key, takeCaller(skip))
+}
+
+// takeStacktrace formats the stack above the caller of its caller, skipping skip frames more.
+func takeStacktrace(skip int) string {
+	pcs := make([]uintptr, 64)
+	for {
+		// skip runtime.Callers, takeStacktrace and the field constructor
+		n := runtime.Callers(skip+3, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+	return FormatStack(pcs)
+}
+
+// FormatStack formats program counters, e.g. from runtime.Callers, like Stack:
+// the function, then the file and line indented, for each frame.
+func FormatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// takeCaller formats the caller of its caller, skipping skip frames more.
+func takeCaller(skip int) string {
+	// skip runtime.Caller, takeCaller and the field constructor
+	pc, file, line, ok := runtime.Caller(skip + 2)
+	if !ok {
+		return "unknown"
+	}
+
+	function := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		function = fn.Name()
+		if i := strings.LastIndexByte(function, '/'); i >= 0 {
+			function = function[i+1:]
+		}
+	}
+	return trimPath(file) + ":" + strconv.Itoa(line) + ":" + function
+}
+
+// trimPath keeps the last directory and the file name.
+func trimPath(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i <= 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
@@ -0,0 +1,324 @@
+// Package xhttp traces and logs net/http servers and clients with xlog.
+package xhttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// DefaultRequestIDHeader is the header the request ID is read from and echoed in.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// MiddlewareOption is a function that configures Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	route           func(r *http.Request) string
+	requestIDHeader string
+	traceIDHeader   string
+	accessLogLevel  xlog.Level
+	levelTrigger    *xlog.LevelTrigger
+}
+
+// WithRoute sets the function returning the route of the request, e.g. "/users/{id}",
+// used in the span name, the "http.route" attribute and the fields of the logger.
+// By default, the route is the pattern matched by the http.ServeMux wrapped by the middleware,
+// known once the request is handled, so it only reaches the span and the access log.
+func WithRoute(fn func(r *http.Request) string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.route = fn
+	}
+}
+
+// WithRequestIDHeader sets the header the request ID is read from and echoed in.
+// A request ID is generated when the request has none. The default is DefaultRequestIDHeader.
+func WithRequestIDHeader(name string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.requestIDHeader = name
+	}
+}
+
+// WithTraceIDHeader makes the middleware return the trace ID of the request in the response header.
+func WithTraceIDHeader(name string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.traceIDHeader = name
+	}
+}
+
+// WithAccessLogLevel sets the level of the access log entry of the requests without a server error.
+// Server errors (5xx) are logged at ErrorLevel. Levels above FatalLevel disable the access log.
+// The default is InfoLevel.
+func WithAccessLogLevel(level xlog.Level) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.accessLogLevel = level
+	}
+}
+
+// WithLevelTrigger makes the middleware apply the level requested by the request, see xlog.LevelTrigger,
+// to its context with xlog.WithLevel, once the baggage is extracted. The access log uses the level too.
+func WithLevelTrigger(trigger xlog.LevelTrigger) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.levelTrigger = &trigger
+	}
+}
+
+// Middleware traces and logs the requests handled by next:
+//
+//   - it extracts the remote trace context and starts a server span with WithOperationSpanOpts,
+//     with the attributes of the OTel HTTP semantic conventions,
+//   - it puts a logger with the "method", "route" and "request_id" fields in the request context,
+//   - it recovers panics with xlog.RecoverAndReturn and replies 500 if nothing was written,
+//     except http.ErrAbortHandler, which aborts the request without being logged,
+//   - it logs "request completed" with the status, the size of the response and the duration.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", getUser)
+//	server := &http.Server{Handler: xhttp.Middleware(mux, xhttp.WithTraceIDHeader("X-Trace-ID"))}
+func Middleware(next http.Handler, options ...MiddlewareOption) http.Handler {
+	opts := &middlewareOptions{
+		route:           func(r *http.Request) string { return routeFromPattern(r.Pattern) },
+		requestIDHeader: DefaultRequestIDHeader,
+		accessLogLevel:  xlog.InfoLevel,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(next, opts, w, r)
+	})
+}
+
+func serve(next http.Handler, opts *middlewareOptions, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	route := opts.route(r)
+
+	requestID := r.Header.Get(opts.requestIDHeader)
+	if requestID == "" {
+		requestID = rand.Text()
+	}
+	w.Header().Set(opts.requestIDHeader, requestID)
+
+	ctx := xlog.ExtractHeaders(r.Context(), r.Header)
+	if opts.levelTrigger != nil {
+		if level, ok := opts.levelTrigger.LevelFromRequest(r.WithContext(ctx)); ok {
+			ctx = xlog.WithLevel(ctx, level)
+		}
+	}
+	ctx, span := xlog.WithOperationSpanOpts(ctx, spanName(r.Method, route),
+		xlog.SpanKind(trace.SpanKindServer),
+		xlog.SpanStartOptions(trace.WithAttributes(requestAttributes(r, route)...)),
+	)
+	// not deferred directly, so the span doesn't record the panic of http.ErrAbortHandler
+	defer func() { span.End() }()
+
+	fields := []xfield.Field{xfield.String("method", r.Method)}
+	if route != "" {
+		fields = append(fields, xfield.String("route", route))
+	}
+	ctx = xlog.WithFields(ctx, append(fields, xfield.String("request_id", requestID))...)
+
+	if opts.traceIDHeader != "" && span.SpanContext().HasTraceID() {
+		w.Header().Set(opts.traceIDHeader, span.SpanContext().TraceID().String())
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	r = r.WithContext(ctx)
+	err := serveRecovered(next, rw, r)
+	if err != nil && rw.status == 0 {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+
+	// the logger has the route already known, the access log gets the route found by the mux
+	var lateRoute string
+	if route == "" {
+		if lateRoute = opts.route(r); lateRoute != "" {
+			span.SetName(spanName(r.Method, lateRoute))
+			span.SetAttributes(semconv.HTTPRoute(lateRoute))
+		}
+	}
+	status := rw.statusCode()
+	span.SetAttributes(
+		semconv.HTTPResponseStatusCode(status),
+		semconv.HTTPResponseBodySize(int(rw.bytes)),
+	)
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, "")
+	}
+
+	logAccess(r, opts.accessLogLevel, lateRoute, status, rw.bytes, time.Since(start))
+}
+
+// serveRecovered calls next and returns the *xlog.PanicError of its panic.
+// http.ErrAbortHandler is not recovered, net/http handles it silently.
+func serveRecovered(next http.Handler, w http.ResponseWriter, r *http.Request) (err error) {
+	defer xlog.RecoverAndReturn(r.Context(), &err, xlog.PassThrough(isAbortHandler))
+	next.ServeHTTP(w, r)
+	return nil
+}
+
+func isAbortHandler(value any) bool {
+	err, ok := value.(error)
+	return ok && errors.Is(err, http.ErrAbortHandler)
+}
+
+func logAccess(r *http.Request, level xlog.Level, route string, status int, size int64, duration time.Duration) {
+	if level > xlog.FatalLevel {
+		return
+	}
+	if status >= http.StatusInternalServerError {
+		level = xlog.ErrorLevel
+	}
+
+	fields := []xfield.Field{
+		xfield.String("path", r.URL.Path),
+		xfield.Int("status", status),
+		xfield.Int64("bytes", size),
+		xfield.Duration("duration", duration),
+		xfield.String("remote_addr", r.RemoteAddr),
+		xfield.String("user_agent", r.UserAgent()),
+	}
+	if route != "" {
+		fields = append(fields, xfield.String("route", route))
+	}
+	xlog.Log(r.Context(), level, "request completed", fields...)
+}
+
+// spanName returns "{method} {route}", or the method alone when the route is unknown,
+// as recommended by the HTTP semantic conventions.
+func spanName(method, route string) string {
+	if route == "" {
+		return method
+	}
+	return method + " " + route
+}
+
+// routeFromPattern returns the path of a http.ServeMux pattern, "[METHOD ][HOST]/[PATH]".
+func routeFromPattern(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[i:]
+	}
+	return ""
+}
+
+func requestAttributes(r *http.Request, route string) []attribute.KeyValue {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(r.Method),
+		semconv.URLScheme(scheme),
+		semconv.URLPath(r.URL.Path),
+		semconv.NetworkProtocolVersion(protocolVersion(r.ProtoMajor, r.ProtoMinor)),
+	}
+	if host, port, err := net.SplitHostPort(r.Host); err == nil {
+		attrs = append(attrs, semconv.ServerAddress(host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, semconv.ServerPort(p))
+		}
+	} else if r.Host != "" {
+		attrs = append(attrs, semconv.ServerAddress(r.Host))
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attrs = append(attrs, semconv.ClientAddress(host))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+	return attrs
+}
+
+// protocolVersion formats the HTTP version as "1.1" or "2".
+func protocolVersion(major, minor int) string {
+	if minor == 0 && major >= 2 {
+		return strconv.Itoa(major)
+	}
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor)
+}
+
+// responseWriter records the status code and the size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code of the response.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the size of the response.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports it.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection if the wrapped writer supports it,
+// e.g. for WebSocket upgrades. The response is then recorded as 101 Switching Protocols.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// ReadFrom copies src to the response with the io.ReaderFrom of the wrapped writer if it has one,
+// e.g. to use sendfile, and records the size of the response.
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := io.Copy(w.ResponseWriter, src)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,269 @@
+package xhttp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ruko1202/xlog"
+)
+
+func setupTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	spanRecorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return spanRecorder
+}
+
+func initTestLogger(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(xlog.ReplaceGlobalLogger(xlog.NewZapAdapter(zap.New(core))))
+	return logs
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("traces and logs the request", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logs := initTestLogger(t)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			xlog.Info(r.Context(), "loading user")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, "hello")
+		})
+		server := httptest.NewServer(Middleware(mux, WithTraceIDHeader("X-Trace-ID")))
+		defer server.Close()
+
+		clientCtx, clientSpan := otel.Tracer("test").Start(context.Background(), "client")
+		req, err := http.NewRequestWithContext(clientCtx, http.MethodGet, server.URL+"/users/42", nil)
+		require.NoError(t, err)
+		req.Header.Set(DefaultRequestIDHeader, "req-1")
+		xlog.InjectHeaders(clientCtx, req.Header)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		clientSpan.End()
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "req-1", resp.Header.Get(DefaultRequestIDHeader))
+		assert.Equal(t, clientSpan.SpanContext().TraceID().String(), resp.Header.Get("X-Trace-ID"))
+
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "loading user", entries[0].Message)
+		assert.Equal(t, "GET", entries[0].ContextMap()["method"])
+		assert.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+		assert.Equal(t, "request completed", entries[1].Message)
+		assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+		access := entries[1].ContextMap()
+		assert.Equal(t, "/users/{id}", access["route"])
+		assert.Equal(t, "/users/42", access["path"])
+		assert.EqualValues(t, http.StatusCreated, access["status"])
+		assert.EqualValues(t, 5, access["bytes"])
+		assert.Contains(t, access, "duration")
+		assert.Equal(t, clientSpan.SpanContext().TraceID().String(), access["trace_id"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		span := spans[0]
+		assert.Equal(t, "GET /users/{id}", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, clientSpan.SpanContext().SpanID(), span.Parent().SpanID())
+		attrs := spanAttributes(span)
+		assert.Equal(t, "GET", attrs["http.request.method"].AsString())
+		assert.Equal(t, "/users/{id}", attrs["http.route"].AsString())
+		assert.Equal(t, "/users/42", attrs["url.path"].AsString())
+		assert.Equal(t, int64(http.StatusCreated), attrs["http.response.status_code"].AsInt64())
+		assert.Equal(t, int64(5), attrs["http.response.body.size"].AsInt64())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("route option and generated request id", func(t *testing.T) {
+		setupTestTracer(t)
+		logs := initTestLogger(t)
+
+		handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			xlog.Info(r.Context(), "handled")
+		}), WithRoute(func(*http.Request) string { return "/static" }), WithAccessLogLevel(xlog.DebugLevel))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static", nil))
+
+		requestID := rec.Header().Get(DefaultRequestIDHeader)
+		assert.NotEmpty(t, requestID)
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "/static", entries[0].ContextMap()["route"])
+		assert.Equal(t, requestID, entries[0].ContextMap()["request_id"])
+		assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+		assert.EqualValues(t, http.StatusOK, entries[1].ContextMap()["status"])
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logs := initTestLogger(t)
+
+		handler := Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "panic recovered", entries[0].Message)
+		assert.Equal(t, "request completed", entries[1].Message)
+		assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "POST", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Len(t, spans[0].Events(), 1)
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	})
+
+	t.Run("re-panics http.ErrAbortHandler without logging it", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logs := initTestLogger(t)
+
+		handler := Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+
+		assert.Zero(t, logs.Len())
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.NotEqual(t, codes.Error, spans[0].Status().Code)
+		assert.Empty(t, spans[0].Events())
+	})
+
+	t.Run("access log can be disabled", func(t *testing.T) {
+		setupTestTracer(t)
+		logs := initTestLogger(t)
+
+		handler := Middleware(http.NotFoundHandler(), WithAccessLogLevel(xlog.FatalLevel+1))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Zero(t, logs.Len())
+	})
+
+	t.Run("level trigger", func(t *testing.T) {
+		setupTestTracer(t)
+		core, logs := observer.New(zapcore.InfoLevel)
+		t.Cleanup(xlog.ReplaceGlobalLogger(xlog.NewZapAdapter(zap.New(core))))
+
+		handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			xlog.Debug(r.Context(), "details")
+		}), WithLevelTrigger(xlog.DefaultLevelTrigger()))
+
+		for _, value := range []string{"", "debug", "fatal"} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(xlog.LevelHeader, value)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("baggage", xlog.LevelBaggageMember+"=1")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		var messages []string
+		for _, entry := range logs.All() {
+			messages = append(messages, entry.Message)
+		}
+		assert.Equal(t, []string{
+			"request completed",
+			"details", "request completed",
+			"request completed", // a level raising the level of the logger is ignored
+			"details", "request completed",
+		}, messages)
+	})
+}
+
+// hijackRecorder is a ResponseRecorder supporting http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestResponseWriter(t *testing.T) {
+	t.Run("records status and size", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := &responseWriter{ResponseWriter: rec}
+
+		rw.Flush()
+		_, _ = rw.Write([]byte("abc"))
+		rw.WriteHeader(http.StatusTeapot)
+		n, err := rw.ReadFrom(strings.NewReader("defg"))
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 4, n)
+		assert.Equal(t, http.StatusOK, rw.statusCode())
+		assert.EqualValues(t, 7, rw.bytes)
+		assert.Equal(t, "abcdefg", rec.Body.String())
+		assert.True(t, rec.Flushed)
+		assert.Same(t, rec, rw.Unwrap())
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		rw := &responseWriter{ResponseWriter: &hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}}
+
+		var w http.ResponseWriter = rw
+		hijacker, ok := w.(http.Hijacker)
+		require.True(t, ok)
+		conn, _, err := hijacker.Hijack()
+		require.NoError(t, err)
+		assert.Same(t, server, conn)
+		assert.Equal(t, http.StatusSwitchingProtocols, rw.statusCode())
+
+		_, _, err = (&responseWriter{ResponseWriter: httptest.NewRecorder()}).Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+	})
+}
@@ -0,0 +1,264 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// TransportOption is a function that configures NewTransport.
+type TransportOption func(*transportOptions)
+
+type transportOptions struct {
+	requestLevel  xlog.Level
+	responseLevel xlog.Level
+	errorLevel    xlog.Level
+	allowHeaders  map[string]bool
+	denyHeaders   map[string]bool // in addition to defaultDenyHeaders when there is no allow list
+	bodyLimit     int64
+}
+
+// defaultDenyHeaders are the headers not logged unless allowed by WithHeaderAllowList.
+var defaultDenyHeaders = headerSet([]string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"})
+
+// WithRequestLogLevel sets the level of the entry logged before sending a request.
+// Levels above FatalLevel disable it. The default is DebugLevel.
+func WithRequestLogLevel(level xlog.Level) TransportOption {
+	return func(o *transportOptions) {
+		o.requestLevel = level
+	}
+}
+
+// WithResponseLogLevel sets the level of the entry logged when a response is received.
+// Levels above FatalLevel disable it. The default is DebugLevel.
+func WithResponseLogLevel(level xlog.Level) TransportOption {
+	return func(o *transportOptions) {
+		o.responseLevel = level
+	}
+}
+
+// WithErrorLogLevel sets the level of the entry logged when a request fails without a response.
+// Levels above FatalLevel disable it. The default is ErrorLevel.
+func WithErrorLogLevel(level xlog.Level) TransportOption {
+	return func(o *transportOptions) {
+		o.errorLevel = level
+	}
+}
+
+// WithHeaderAllowList logs only the given request and response headers, even the ones denied by default.
+// By default, all headers are logged except Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+func WithHeaderAllowList(names ...string) TransportOption {
+	return func(o *transportOptions) {
+		o.allowHeaders = headerSet(names)
+	}
+}
+
+// WithHeaderDenyList never logs the given headers, even if allowed by WithHeaderAllowList.
+func WithHeaderDenyList(names ...string) TransportOption {
+	return func(o *transportOptions) {
+		for _, name := range names {
+			o.denyHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithBodyLimit logs up to limit bytes of the request and response bodies. The request body is
+// read up to the limit before the request is sent, and the response body before RoundTrip returns,
+// so avoid it with streaming bodies. Bodies are only read when the logger from the request context
+// writes the entry. The default is 0, bodies are not logged.
+func WithBodyLimit(limit int64) TransportOption {
+	return func(o *transportOptions) {
+		o.bodyLimit = limit
+	}
+}
+
+// transport is the http.RoundTripper returned by NewTransport.
+type transport struct {
+	base http.RoundTripper
+	opts *transportOptions
+}
+
+// NewTransport returns an http.RoundTripper tracing and logging the requests sent through base,
+// http.DefaultTransport if nil:
+//
+//   - it starts a client span from the request context, with the attributes of the OTel HTTP
+//     semantic conventions, and injects the trace context in the request headers,
+//   - it sets the status of the span from the response, and records errors with xlog.RecordSpanError,
+//   - it logs the request and the response with the logger from the request context.
+//
+// Example:
+//
+//	client := &http.Client{Transport: xhttp.NewTransport(nil, xhttp.WithResponseLogLevel(xlog.InfoLevel))}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/users/42", nil)
+//	resp, err := client.Do(req)
+func NewTransport(base http.RoundTripper, options ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	opts := &transportOptions{
+		requestLevel:  xlog.DebugLevel,
+		responseLevel: xlog.DebugLevel,
+		errorLevel:    xlog.ErrorLevel,
+		denyHeaders:   make(map[string]bool),
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return &transport{base: base, opts: opts}
+}
+
+// RoundTrip sends the request through the base transport in a client span.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx, span := xlog.TracerFromContext(req.Context()).Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(clientAttributes(req)...),
+	)
+	defer span.End()
+
+	// the entries get the IDs of the span, without marking it: RecordSpanError does
+	logCtx := trace.ContextWithSpanContext(ctx, span.SpanContext())
+
+	req = req.Clone(ctx)
+	xlog.InjectHeaders(ctx, req.Header)
+
+	fields := []xfield.Field{
+		xfield.String("method", req.Method),
+		xfield.String("url", req.URL.Redacted()),
+	}
+	if t.logs(logCtx, t.opts.requestLevel) {
+		requestFields := t.headerFields(fields, req.Header)
+		if body, ok := t.captureRequestBody(req); ok {
+			requestFields = append(requestFields, xfield.String("body", body))
+		}
+		xlog.Log(logCtx, t.opts.requestLevel, "http request", requestFields...)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	fields = append(fields, xfield.Duration("duration", time.Since(start)))
+	if err != nil {
+		xlog.RecordSpanError(ctx, err)
+		if t.logs(logCtx, t.opts.errorLevel) {
+			xlog.Log(logCtx, t.opts.errorLevel, "http request failed", append(fields, xfield.Error(err))...)
+		}
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, "")
+	}
+	if t.logs(logCtx, t.opts.responseLevel) {
+		responseFields := t.headerFields(append(fields, xfield.Int("status", resp.StatusCode)), resp.Header)
+		if body, ok := t.captureResponseBody(resp); ok {
+			responseFields = append(responseFields, xfield.String("body", body))
+		}
+		xlog.Log(logCtx, t.opts.responseLevel, "http response", responseFields...)
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the base transport if it supports it.
+func (t *transport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// logs reports whether an entry at the level is written by the logger from the context,
+// so the headers and bodies of dropped entries aren't formatted nor read.
+func (t *transport) logs(ctx context.Context, level xlog.Level) bool {
+	if level > xlog.FatalLevel {
+		return false
+	}
+	if le, ok := xlog.LoggerFromContext(ctx).(xlog.LevelEnabler); ok {
+		return le.Enabled(level)
+	}
+	return true
+}
+
+// headerFields appends the "headers" group with the headers allowed to be logged.
+func (t *transport) headerFields(fields []xfield.Field, header http.Header) []xfield.Field {
+	var headers []xfield.Field
+	for name, values := range header {
+		allowed := !defaultDenyHeaders[name]
+		if t.opts.allowHeaders != nil {
+			allowed = t.opts.allowHeaders[name]
+		}
+		if !allowed || t.opts.denyHeaders[name] {
+			continue
+		}
+		headers = append(headers, xfield.String(name, strings.Join(values, ", ")))
+	}
+	if len(headers) == 0 {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)], xfield.Group("headers", headers...))
+}
+
+// captureRequestBody reads the request body up to the limit and restores it.
+func (t *transport) captureRequestBody(req *http.Request) (string, bool) {
+	if t.opts.bodyLimit <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return "", false
+	}
+	captured, body := captureBody(req.Body, t.opts.bodyLimit)
+	req.Body = body
+	return captured, true
+}
+
+// captureResponseBody reads the response body up to the limit and restores it.
+func (t *transport) captureResponseBody(resp *http.Response) (string, bool) {
+	if t.opts.bodyLimit <= 0 || resp.Body == nil || resp.Body == http.NoBody {
+		return "", false
+	}
+	captured, body := captureBody(resp.Body, t.opts.bodyLimit)
+	resp.Body = body
+	return captured, true
+}
+
+// captureBody reads body up to limit bytes and returns them with a body reading them again first.
+func captureBody(body io.ReadCloser, limit int64) (string, io.ReadCloser) {
+	captured, _ := io.ReadAll(io.LimitReader(body, limit))
+	return string(captured), struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), body), body}
+}
+
+func clientAttributes(req *http.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(req.URL.Redacted()),
+	}
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if host != "" {
+		attrs = append(attrs, semconv.ServerAddress(host))
+	}
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[req.URL.Scheme]
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	return attrs
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
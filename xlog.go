@@ -7,15 +7,19 @@ package xlog
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 )
 
 type xlogCtxKey int
 
 const (
 	loggerCtxKey xlogCtxKey = iota
+	eventCtxKey
+	levelCtxKey
 )
 
 // ContextWithLogger adds a logger to the context and returns a new context.
+// If the context carries a level set by WithLevel, the level is applied to the logger.
 //
 // Example:
 //
@@ -25,6 +29,9 @@ func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
 	if logger == nil {
 		logger = GlobalLogger()
 	}
+	if level, ok := levelFromContext(ctx); ok {
+		logger = overrideLevel(logger, level)
+	}
 	return context.WithValue(ctx, loggerCtxKey, logger)
 }
 
@@ -94,3 +101,57 @@ func GlobalLogger() Logger {
 func L() Logger {
 	return GlobalLogger()
 }
+
+// stacktraceKey is the key of the stack trace added by ReplaceStacktraceLevel, as zap's default.
+const stacktraceKey = "stacktrace"
+
+// _stacktraceLevel is the minimum level of the entries getting a stack trace.
+// The default is above FatalLevel, so no entry gets one.
+var _stacktraceLevel atomic.Int32
+
+func init() {
+	_stacktraceLevel.Store(int32(FatalLevel + 1))
+}
+
+// ReplaceStacktraceLevel makes the package-level helpers (xlog.Error, ...) add a "stacktrace" field
+// to the entries at or above the level, whatever the logger backend, like zap's AddStacktrace.
+// When the entry also marks the span as failed, the exception event reuses the same stack.
+// Stack traces are disabled by default. Returns a function restoring the previous level.
+// This function is thread-safe and can be called concurrently.
+//
+// Example:
+//
+//	restore := xlog.ReplaceStacktraceLevel(xlog.ErrorLevel)
+//	defer restore()
+func ReplaceStacktraceLevel(level Level) func() {
+	prev := Level(_stacktraceLevel.Swap(int32(level)))
+	return func() { ReplaceStacktraceLevel(prev) }
+}
+
+func stacktraceLevel() Level {
+	return Level(_stacktraceLevel.Load())
+}
+
+// _development is the global development switch, see ReplaceDevelopment.
+var _development atomic.Bool
+
+// ReplaceDevelopment turns the global development switch on or off, like zap's Development option:
+// in development, DPanic logs the entry and then panics, while in production it only logs it.
+// It applies to the package-level helpers, the native loggers, NoopLogger and the SlogAdapter
+// created without WithDevelopment. Zap loggers follow their own Development option.
+// The switch is off by default. Returns a function restoring the previous value.
+// This function is thread-safe and can be called concurrently.
+//
+// Example:
+//
+//	restore := xlog.ReplaceDevelopment(true)
+//	defer restore()
+func ReplaceDevelopment(enabled bool) func() {
+	prev := _development.Swap(enabled)
+	return func() { ReplaceDevelopment(prev) }
+}
+
+// IsDevelopment reports whether the global development switch is on, see ReplaceDevelopment.
+func IsDevelopment() bool {
+	return _development.Load()
+}
@@ -0,0 +1,227 @@
+package xlog
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// maxStackDepth limits the number of frames captured by NewErr and WrapErr.
+const maxStackDepth = 32
+
+// fieldError is an error carrying structured fields and the stack where it was created.
+type fieldError struct {
+	err    error
+	fields []xfield.Field
+	stack  []uintptr
+}
+
+// NewErr formats an error like fmt.Errorf, including %w wrapping, and captures the current stack.
+// Use WrapErr to attach fields to it.
+//
+// Example:
+//
+//	return xlog.WrapErr(xlog.NewErr("user %s not found", id), xfield.String("user_id", id))
+func NewErr(format string, args ...any) error {
+	return &fieldError{
+		err:   fmt.Errorf(format, args...),
+		stack: callers(),
+	}
+}
+
+// WrapErr attaches the fields to err, so they are added to the entry when the error is logged
+// by the package-level helpers (xlog.Error, ...). The stack is captured unless an error in the
+// chain already has one. The message is unchanged and errors.Is and errors.As see through the
+// wrapper. WrapErr returns nil if err is nil.
+//
+// Example:
+//
+//	if err := db.QueryRowContext(ctx, query, id).Scan(&user); err != nil {
+//		return xlog.WrapErr(err, xfield.String("user_id", id))
+//	}
+func WrapErr(err error, fields ...xfield.Field) error {
+	if err == nil {
+		return nil
+	}
+
+	wrapped := &fieldError{err: err, fields: fields}
+	if !hasStack(err) {
+		wrapped.stack = callers()
+	}
+	return wrapped
+}
+
+// ErrorFields returns the fields attached to the error chain by NewErr and WrapErr,
+// the outermost first.
+func ErrorFields(err error) []xfield.Field {
+	var fields []xfield.Field
+	walkErrors(err, func(err error) {
+		if fe, ok := err.(*fieldError); ok {
+			fields = append(fields, fe.fields...)
+		}
+	})
+	return fields
+}
+
+// Error returns the message of the wrapped error.
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// Format prints the message and, with %+v, the stack trace.
+func (e *fieldError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = fmt.Fprintf(s, "%+v", e.err)
+		if len(e.stack) > 0 {
+			_, _ = io.WriteString(s, "\n"+xfield.FormatStack(e.stack))
+		}
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs) // skip runtime.Callers, callers and NewErr or WrapErr
+	return pcs[:n]
+}
+
+// errorStack returns the stack captured for the error chain by NewErr, WrapErr or a recovered panic,
+// the outermost first.
+func errorStack(err error) []uintptr {
+	var stack []uintptr
+	walkErrors(err, func(err error) {
+		if stack != nil {
+			return
+		}
+		switch e := err.(type) {
+		case *fieldError:
+			if len(e.stack) > 0 {
+				stack = e.stack
+			}
+		case *PanicError:
+			if len(e.stack) > 0 {
+				stack = e.stack
+			}
+		}
+	})
+	return stack
+}
+
+func hasStack(err error) bool {
+	return errorStack(err) != nil
+}
+
+// walkErrors calls fn for err and every error it wraps, depth-first,
+// following errors.Unwrap and the trees of errors.Join and fmt.Errorf with several %w.
+func walkErrors(err error, fn func(err error)) {
+	if err == nil {
+		return
+	}
+
+	fn(err)
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(wrapped.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, e := range wrapped.Unwrap() {
+			walkErrors(e, fn)
+		}
+	}
+}
+
+// errorChain returns the messages of err and the errors it wraps, depth-first.
+// The wrappers created by NewErr and WrapErr are skipped, as they repeat the message.
+func errorChain(err error) []string {
+	var chain []string
+	walkErrors(err, func(err error) {
+		if _, ok := err.(*fieldError); !ok {
+			chain = append(chain, err.Error())
+		}
+	})
+	return chain
+}
+
+// errorDetailFields returns the fields describing the error logged under key: key.message, key.type,
+// key.chain when it wraps other errors, key.stack when captured by NewErr, WrapErr or a recovered panic,
+// followed by the fields attached to the chain.
+func errorDetailFields(key string, err error) []xfield.Field {
+	fields := []xfield.Field{
+		xfield.String(key+".message", err.Error()),
+		xfield.String(key+".type", errorType(err)),
+	}
+	if chain := errorChain(err); len(chain) > 1 {
+		fields = append(fields, xfield.Strings(key+".chain", chain))
+	}
+	if stack := errorStack(err); stack != nil {
+		fields = append(fields, xfield.String(key+".stack", xfield.FormatStack(stack)))
+	}
+	return append(fields, ErrorFields(err)...)
+}
+
+// withErrorDetails appends the details of the non-nil error fields, see errorDetailFields.
+// The error fields themselves are kept and hold the message.
+func withErrorDetails(fields []xfield.Field) []xfield.Field {
+	var details []xfield.Field
+	for i := range fields {
+		if fields[i].Type != xfield.ErrorType {
+			continue
+		}
+		if err, ok := fields[i].Interface.(error); ok && err != nil {
+			details = append(details, errorDetailFields(fields[i].Key, err)...)
+		}
+	}
+	if len(details) == 0 {
+		return fields
+	}
+
+	result := make([]xfield.Field, 0, len(fields)+len(details))
+	result = append(result, fields...)
+	return append(result, details...)
+}
+
+// isWrapper reports whether err only adds context to the error it wraps: NewErr and WrapErr,
+// and the errors of unexported types with a single cause whose message ends with the message
+// of the cause, such as fmt.Errorf with a single %w. Exported types such as *fs.PathError are
+// kept, as callers match them with errors.As.
+func isWrapper(err error) bool {
+	if _, ok := err.(*fieldError); ok {
+		return true
+	}
+	wrapper, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		return false
+	}
+	cause := wrapper.Unwrap()
+	if cause == nil || !strings.HasSuffix(err.Error(), cause.Error()) {
+		return false
+	}
+	typ := reflect.TypeOf(err)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return !token.IsExported(typ.Name())
+}
+
+// unwrapCause returns the first error of the chain that is not a wrapper, see isWrapper.
+func unwrapCause(err error) error {
+	for isWrapper(err) {
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			return err
+		}
+		err = cause
+	}
+	return err
+}
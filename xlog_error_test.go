@@ -0,0 +1,138 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func TestNewErr(t *testing.T) {
+	cause := fs.ErrNotExist
+	err := NewErr("load config %s: %w", "app.yaml", cause)
+
+	assert.Equal(t, "load config app.yaml: file does not exist", err.Error())
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, ErrorFields(err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.Contains(t, verbose, "load config app.yaml: file does not exist\ngithub.com/ruko1202/xlog.TestNewErr\n\t")
+	assert.Contains(t, verbose, "xlog_error_test.go:")
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+}
+
+func TestWrapErr(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.NoError(t, WrapErr(nil, xfield.String("k", "v")))
+	})
+
+	t.Run("attaches fields and keeps the message", func(t *testing.T) {
+		pathErr := &fs.PathError{Op: "open", Path: "/etc/app", Err: fs.ErrPermission}
+		err := WrapErr(fmt.Errorf("read config: %w", WrapErr(pathErr, xfield.String("path", "/etc/app"))),
+			xfield.Int("attempt", 2))
+
+		assert.Equal(t, "read config: open /etc/app: permission denied", err.Error())
+		var target *fs.PathError
+		require.ErrorAs(t, err, &target)
+		assert.Same(t, pathErr, target)
+		assert.Equal(t, []xfield.Field{xfield.Int("attempt", 2), xfield.String("path", "/etc/app")}, ErrorFields(err))
+	})
+
+	t.Run("captures the stack once", func(t *testing.T) {
+		inner := NewErr("inner")
+		outer := WrapErr(inner, xfield.String("k", "v"))
+
+		assert.Equal(t, errorStack(inner), errorStack(outer))
+		assert.NotEmpty(t, errorStack(WrapErr(errors.New("plain"))))
+	})
+
+	t.Run("collects fields from joined errors", func(t *testing.T) {
+		err := errors.Join(
+			WrapErr(errors.New("first"), xfield.String("a", "1")),
+			fmt.Errorf("second: %w", WrapErr(errors.New("cause"), xfield.String("b", "2"))),
+		)
+
+		assert.Equal(t, []xfield.Field{xfield.String("a", "1"), xfield.String("b", "2")}, ErrorFields(err))
+		assert.Equal(t, []string{"first\nsecond: cause", "first", "second: cause", "cause"}, errorChain(err))
+	})
+}
+
+func TestLogErrorDetails(t *testing.T) {
+	t.Run("adds message, type, chain, stack and attached fields", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		pathErr := &fs.PathError{Op: "open", Path: "/etc/app", Err: fs.ErrNotExist}
+		err := fmt.Errorf("load: %w", WrapErr(pathErr, xfield.String("user_id", "42")))
+		Error(ctx, "failed", xfield.Error(err))
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "load: open /etc/app: file does not exist", fields["error"])
+		assert.Equal(t, "load: open /etc/app: file does not exist", fields["error.message"])
+		assert.Equal(t, "*fs.PathError", fields["error.type"])
+		assert.Equal(t, []any{
+			"load: open /etc/app: file does not exist",
+			"open /etc/app: file does not exist",
+			"file does not exist",
+		}, fields["error.chain"])
+		assert.Contains(t, fields["error.stack"], "github.com/ruko1202/xlog.TestLogErrorDetails")
+		assert.Equal(t, "42", fields["user_id"])
+	})
+
+	t.Run("plain errors get their type and chain", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		_, openErr := os.Open(filepath.Join(t.TempDir(), "missing"))
+		Warn(ctx, "failed",
+			xfield.NamedError("cause", fmt.Errorf("load config: %w", openErr)),
+			xfield.NamedError("joined", errors.Join(errors.New("first"), errors.New("second"))),
+			xfield.NamedError("plain", errors.New("boom")),
+			xfield.Error(nil),
+		)
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "*fs.PathError", fields["cause.type"])
+		assert.Equal(t, []any{"load config: " + openErr.Error(), openErr.Error(), "no such file or directory"}, fields["cause.chain"])
+		assert.Equal(t, "*errors.joinError", fields["joined.type"])
+		assert.Equal(t, []any{"first\nsecond", "first", "second"}, fields["joined.chain"])
+		assert.Equal(t, "*errors.errorString", fields["plain.type"])
+		assert.Equal(t, "boom", fields["plain.message"])
+		for _, key := range []string{"cause.stack", "joined.stack", "plain.chain", "plain.stack"} {
+			assert.NotContains(t, fields, key)
+		}
+	})
+
+	t.Run("span event gets attached fields and the captured stack", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, _ := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, span := WithOperationSpan(ctx, "test")
+		Error(ctx, "failed", xfield.Error(WrapErr(errors.New("boom"), xfield.String("user_id", "42"))))
+		span.End()
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		require.Len(t, spans[0].Events(), 1)
+		event := attribute.NewSet(spans[0].Events()[0].Attributes...)
+
+		userID, ok := event.Value("user_id")
+		require.True(t, ok)
+		assert.Equal(t, "42", userID.AsString())
+		stack, ok := event.Value("exception.stacktrace")
+		require.True(t, ok)
+		assert.Contains(t, stack.AsString(), "github.com/ruko1202/xlog.TestLogErrorDetails")
+	})
+}
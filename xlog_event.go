@@ -0,0 +1,308 @@
+package xlog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// DefaultEventMaxFields is the default limit of distinct keys an Event accumulates.
+const DefaultEventMaxFields = 64
+
+const (
+	eventStatusOK    = "ok"
+	eventStatusError = "error"
+)
+
+// eventSummaryKeys are the keys End adds to the accumulated ones.
+var eventSummaryKeys = map[string]struct{}{
+	"duration":       {},
+	"status":         {},
+	"error":          {},
+	"dropped_fields": {},
+}
+
+// EventOption is a function that configures an Event.
+type EventOption func(*Event)
+
+// EventMaxFields limits the number of distinct keys (fields, counters and timings)
+// the event accumulates. Keys added after the limit is reached are dropped and
+// counted in the "dropped_fields" field. Non-positive values disable the limit.
+func EventMaxFields(n int) EventOption {
+	return func(e *Event) {
+		e.maxFields = n
+	}
+}
+
+// EventSpanAttributes makes End also set the accumulated fields as attributes
+// on the span stored in the context (e.g. the one created by WithOperationSpan).
+func EventSpanAttributes() EventOption {
+	return func(e *Event) {
+		e.spanAttributes = true
+	}
+}
+
+// Event accumulates fields, counters and timings of a single operation
+// and emits them as one log line when ended (canonical log line).
+// All methods are safe for concurrent use and are no-ops on a nil Event.
+type Event struct {
+	ctx            context.Context
+	name           string
+	start          time.Time
+	maxFields      int
+	spanAttributes bool
+
+	mu       sync.Mutex
+	keys     map[string]struct{}
+	fields   []xfield.Field
+	fieldIdx map[string]int
+	counters []xfield.Field
+	timings  []xfield.Field
+	dropped  int
+	status   string
+	err      error
+	ended    bool
+}
+
+// StartEvent starts a new wide event for the given operation and attaches it to the context.
+// Code down the stack can enrich the event with AddEventFields, AddEventCounter and AddEventTiming.
+// The event is emitted by End using the logger found in the context at start time.
+//
+// Example:
+//
+//	ctx, ev := xlog.StartEvent(ctx, "http_request")
+//	defer ev.End()
+//
+//	xlog.AddEventFields(ctx, xfield.String("user_id", userID))
+//	xlog.AddEventCounter(ctx, "db_queries", 1)
+func StartEvent(ctx context.Context, name string, options ...EventOption) (context.Context, *Event) {
+	ev := &Event{
+		name:      name,
+		start:     time.Now(),
+		maxFields: DefaultEventMaxFields,
+		keys:      make(map[string]struct{}),
+		fieldIdx:  make(map[string]int),
+	}
+	for _, opt := range options {
+		opt(ev)
+	}
+
+	ctx = context.WithValue(ctx, eventCtxKey, ev)
+	ev.ctx = ctx
+
+	return ctx, ev
+}
+
+// EventFromContext extracts the event started by StartEvent from the context.
+// If no event is found, returns nil, which is safe to use.
+func EventFromContext(ctx context.Context) *Event {
+	ev, _ := ctx.Value(eventCtxKey).(*Event)
+	return ev
+}
+
+// AddEventFields adds fields to the event stored in the context.
+// A field with an already present key replaces the previous value.
+// If no event is found, this is a no-op.
+func AddEventFields(ctx context.Context, fields ...xfield.Field) {
+	EventFromContext(ctx).AddFields(fields...)
+}
+
+// AddEventCounter increments the named counter of the event stored in the context.
+// If no event is found, this is a no-op.
+func AddEventCounter(ctx context.Context, name string, delta int64) {
+	EventFromContext(ctx).AddCounter(name, delta)
+}
+
+// AddEventTiming adds the duration to the named timing of the event stored in the context.
+// If no event is found, this is a no-op.
+func AddEventTiming(ctx context.Context, name string, d time.Duration) {
+	EventFromContext(ctx).AddTiming(name, d)
+}
+
+// AddFields adds fields to the event.
+// A field with an already present key replaces the previous value.
+// Keys used by a counter or a timing, or by End ("duration", "status", "error"
+// and "dropped_fields"), are dropped and counted in "dropped_fields".
+func (e *Event) AddFields(fields ...xfield.Field) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ended {
+		return
+	}
+	for _, f := range fields {
+		if i, ok := e.fieldIdx[f.Key]; ok {
+			e.fields[i] = f
+			continue
+		}
+		if !e.reserveKey(f.Key) {
+			continue
+		}
+		e.fieldIdx[f.Key] = len(e.fields)
+		e.fields = append(e.fields, f)
+	}
+}
+
+// AddCounter increments the named counter by delta.
+// A name already used by a field or a timing is dropped like in AddFields.
+func (e *Event) AddCounter(name string, delta int64) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ended {
+		return
+	}
+	for i := range e.counters {
+		if e.counters[i].Key == name {
+			e.counters[i].Integer += delta
+			return
+		}
+	}
+	if e.reserveKey(name) {
+		e.counters = append(e.counters, xfield.Int64(name, delta))
+	}
+}
+
+// AddTiming adds the duration to the named timing.
+// Repeated calls with the same name accumulate.
+// A name already used by a field or a counter is dropped like in AddFields.
+func (e *Event) AddTiming(name string, d time.Duration) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ended {
+		return
+	}
+	for i := range e.timings {
+		if e.timings[i].Key == name {
+			e.timings[i].Integer += int64(d)
+			return
+		}
+	}
+	if e.reserveKey(name) {
+		e.timings = append(e.timings, xfield.Duration(name, d))
+	}
+}
+
+// StartTiming starts measuring the named timing and returns a function that stops it.
+//
+// Example:
+//
+//	stop := ev.StartTiming("db")
+//	rows, err := db.QueryContext(ctx, query)
+//	stop()
+func (e *Event) StartTiming(name string) func() {
+	start := time.Now()
+	return func() {
+		e.AddTiming(name, time.Since(start))
+	}
+}
+
+// SetStatus sets the status reported by the event.
+// By default the status is "ok", or "error" when an error is set.
+func (e *Event) SetStatus(status string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.status = status
+	e.mu.Unlock()
+}
+
+// SetError sets the error reported by the event.
+// An event with an error is emitted at Error level.
+func (e *Event) SetError(err error) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.err = err
+	e.mu.Unlock()
+}
+
+// End emits the accumulated event as a single log line with duration, status and error.
+// Only the first call emits; subsequent calls are no-ops.
+func (e *Event) End() {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		return
+	}
+	e.ended = true
+	fields, err := e.summaryFields(), e.err
+	e.mu.Unlock()
+
+	if e.spanAttributes {
+		SetSpanAttributes(e.ctx, fieldsToOtelAttributes(fields)...)
+	}
+
+	if err != nil {
+		Error(e.ctx, e.name, fields...)
+		return
+	}
+	Info(e.ctx, e.name, fields...)
+}
+
+// reserveKey registers a new key if it isn't taken and the field limit allows it.
+// Callers look the key up in their own kind first, so a known key belongs to another kind.
+// Must be called with e.mu held.
+func (e *Event) reserveKey(key string) bool {
+	_, taken := e.keys[key]
+	if !taken {
+		_, taken = eventSummaryKeys[key]
+	}
+	if taken || e.maxFields > 0 && len(e.keys) >= e.maxFields {
+		e.dropped++
+		return false
+	}
+	e.keys[key] = struct{}{}
+	return true
+}
+
+// summaryFields builds the fields of the emitted log line.
+// Must be called with e.mu held.
+func (e *Event) summaryFields() []xfield.Field {
+	fields := make([]xfield.Field, 0, len(e.fields)+len(e.counters)+len(e.timings)+4)
+	fields = append(fields, e.fields...)
+	fields = append(fields, e.counters...)
+	fields = append(fields, e.timings...)
+	fields = append(fields, xfield.Duration("duration", time.Since(e.start)))
+
+	status := e.status
+	if status == "" {
+		status = eventStatusOK
+		if e.err != nil {
+			status = eventStatusError
+		}
+	}
+	fields = append(fields, xfield.String("status", status))
+
+	if e.err != nil {
+		fields = append(fields, xfield.Error(e.err))
+	}
+	if e.dropped > 0 {
+		fields = append(fields, xfield.Int("dropped_fields", e.dropped))
+	}
+
+	return fields
+}
@@ -0,0 +1,212 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func TestStartEvent(t *testing.T) {
+	t.Run("emits single line with accumulated data", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "http_request")
+		AddEventFields(ctx, xfield.String("method", "GET"), xfield.Int("user_id", 42))
+		AddEventCounter(ctx, "db_queries", 1)
+		AddEventCounter(ctx, "db_queries", 2)
+		AddEventTiming(ctx, "db", time.Second)
+		AddEventTiming(ctx, "db", time.Second)
+
+		require.Equal(t, 0, logs.Len())
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.InfoLevel, entry.Level)
+		assert.Equal(t, "http_request", entry.Message)
+
+		fields := entry.ContextMap()
+		assert.Equal(t, "GET", fields["method"])
+		assert.Equal(t, int64(42), fields["user_id"])
+		assert.Equal(t, int64(3), fields["db_queries"])
+		assert.Equal(t, 2*time.Second, fields["db"])
+		assert.Equal(t, "ok", fields["status"])
+		assert.Contains(t, fields, "duration")
+		assert.NotContains(t, fields, "error")
+	})
+
+	t.Run("later field replaces previous value", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "op")
+		AddEventFields(ctx, xfield.String("step", "first"))
+		AddEventFields(ctx, xfield.String("step", "second"))
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "second", logs.All()[0].ContextMap()["step"])
+	})
+
+	t.Run("error switches level and status", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		_, ev := StartEvent(ctx, "op")
+		ev.SetError(errors.New("boom"))
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+		assert.Equal(t, "error", entry.ContextMap()["status"])
+		assert.Equal(t, "boom", entry.ContextMap()["error"])
+	})
+
+	t.Run("custom status", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		_, ev := StartEvent(ctx, "op")
+		ev.SetStatus("not_found")
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "not_found", logs.All()[0].ContextMap()["status"])
+	})
+
+	t.Run("end emits only once", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "op")
+		ev.End()
+		AddEventFields(ctx, xfield.String("late", "value"))
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		assert.NotContains(t, logs.All()[0].ContextMap(), "late")
+	})
+
+	t.Run("caps distinct keys", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "op", EventMaxFields(2))
+		AddEventFields(ctx, xfield.Int("a", 1), xfield.Int("b", 2), xfield.Int("c", 3))
+		AddEventCounter(ctx, "d", 1)
+		AddEventFields(ctx, xfield.Int("a", 10))
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, int64(10), fields["a"])
+		assert.Equal(t, int64(2), fields["b"])
+		assert.NotContains(t, fields, "c")
+		assert.NotContains(t, fields, "d")
+		assert.Equal(t, int64(2), fields["dropped_fields"])
+	})
+
+	t.Run("drops keys taken by another kind", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "op")
+		AddEventFields(ctx, xfield.String("x", "field"), xfield.String("status", "custom"))
+		AddEventCounter(ctx, "x", 1)
+		AddEventCounter(ctx, "y", 1)
+		AddEventTiming(ctx, "y", time.Second)
+		AddEventFields(ctx, xfield.Int("y", 1))
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		var keys []string
+		for _, f := range logs.All()[0].Context {
+			keys = append(keys, f.Key)
+		}
+		assert.Equal(t, []string{"x", "y", "duration", "status", "dropped_fields"}, keys)
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "field", fields["x"])
+		assert.Equal(t, int64(1), fields["y"])
+		assert.Equal(t, "ok", fields["status"])
+		assert.Equal(t, int64(4), fields["dropped_fields"])
+	})
+
+	t.Run("concurrent accumulation", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, ev := StartEvent(ctx, "op")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				AddEventCounter(ctx, "calls", 1)
+				AddEventFields(ctx, xfield.Int(fmt.Sprintf("key_%d", i%5), i))
+				AddEventTiming(ctx, "work", time.Millisecond)
+			}(i)
+		}
+		wg.Wait()
+		ev.End()
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, int64(50), fields["calls"])
+		assert.Equal(t, 50*time.Millisecond, fields["work"])
+	})
+
+	t.Run("sets fields on span", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, _ := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, span := WithOperationSpan(ctx, "handler")
+		ctx, ev := StartEvent(ctx, "http_request", EventSpanAttributes())
+		AddEventFields(ctx, xfield.String("route", "/users"))
+		AddEventCounter(ctx, "cache_hits", 3)
+		ev.SetError(errors.New("boom"))
+		ev.End()
+		span.End()
+
+		spans := spanRecorder.Ended()
+		require.Equal(t, 1, len(spans))
+		attrs := attribute.NewSet(spans[0].Attributes()...)
+		route, ok := attrs.Value("route")
+		require.True(t, ok)
+		assert.Equal(t, "/users", route.AsString())
+		hits, ok := attrs.Value("cache_hits")
+		require.True(t, ok)
+		assert.Equal(t, int64(3), hits.AsInt64())
+		status, ok := attrs.Value("status")
+		require.True(t, ok)
+		assert.Equal(t, "error", status.AsString())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+
+	t.Run("no-op without event in context", func(t *testing.T) {
+		ctx := context.Background()
+
+		assert.Nil(t, EventFromContext(ctx))
+		assert.NotPanics(t, func() {
+			AddEventFields(ctx, xfield.String("key", "value"))
+			AddEventCounter(ctx, "counter", 1)
+			AddEventTiming(ctx, "timing", time.Second)
+			EventFromContext(ctx).StartTiming("timing")()
+			EventFromContext(ctx).End()
+		})
+	})
+}
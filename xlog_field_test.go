@@ -2,6 +2,7 @@ package xlog
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -240,4 +241,37 @@ func TestFieldCreation(t *testing.T) {
 		assert.Equal(t, obj, f.Interface)
 		assert.Equal(t, "{Name:test Age:30}", f.FormatValue())
 	})
+	t.Run("Group field", func(t *testing.T) {
+		f := xfield.Group("http", xfield.String("method", "GET"), xfield.Group("response", xfield.Int("status", 200)))
+		assert.Equal(t, xfield.GroupType, f.Type)
+		assert.Equal(t, "http", f.Key)
+		assert.Len(t, f.Interface, 2)
+		assert.Equal(t, "{method=GET response={status=200}}", f.FormatValue())
+	})
+
+	t.Run("Stack field", func(t *testing.T) {
+		f := xfield.Stack("stacktrace")
+		assert.Equal(t, xfield.StringType, f.Type)
+		assert.Regexp(t, `^github\.com/ruko1202/xlog\.TestFieldCreation\.func\d+\n\t.+/xlog_field_test\.go:\d+\n`, f.String)
+	})
+
+	t.Run("StackSkip field", func(t *testing.T) {
+		helper := func() xfield.Field { return xfield.StackSkip("stacktrace", 1) }
+		firstFrame := func(stack string) string { return strings.SplitN(stack, "\n", 2)[0] }
+
+		assert.Equal(t, firstFrame(xfield.Stack("stacktrace").String), firstFrame(helper().String))
+	})
+
+	t.Run("Caller field", func(t *testing.T) {
+		f := xfield.Caller("caller")
+		assert.Equal(t, xfield.StringType, f.Type)
+		assert.Regexp(t, `^[^/]+/xlog_field_test\.go:\d+:xlog\.TestFieldCreation\.func\d+$`, f.String)
+	})
+
+	t.Run("CallerSkip field", func(t *testing.T) {
+		helper := func() xfield.Field { return xfield.CallerSkip("caller", 1) }
+		function := func(caller string) string { return strings.SplitN(caller, ":", 3)[2] }
+
+		assert.Equal(t, function(xfield.Caller("caller").String), function(helper().String))
+	})
 }
@@ -0,0 +1,182 @@
+package xlog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// Detach returns a context that is never canceled and has no deadline, for work that outlives
+// the request it was started from. It keeps the values of ctx: the logger returned by LoggerFromContext,
+// the tracer returned by TracerFromContext, the span and the level set by WithLevel.
+//
+// Example:
+//
+//	go audit.Record(xlog.Detach(ctx), event)
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// GoOption is a function that configures Go.
+type GoOption func(*goOptions)
+
+type goOptions struct {
+	fields  []xfield.Field
+	child   bool
+	tracker *TaskTracker
+}
+
+// GoFields adds the fields to the logger and as span attributes of the goroutine.
+func GoFields(fields ...xfield.Field) GoOption {
+	return func(o *goOptions) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// GoChildSpan makes the span of the goroutine a child of the span of the context
+// instead of the root of a new trace.
+func GoChildSpan() GoOption {
+	return func(o *goOptions) {
+		o.child = true
+	}
+}
+
+// GoTracker registers the goroutine with the tracker, so TaskTracker.Wait waits for it.
+func GoTracker(tracker *TaskTracker) GoOption {
+	return func(o *goOptions) {
+		o.tracker = tracker
+	}
+}
+
+// Go runs fn in a new goroutine with a detached context (see Detach) and a span created like
+// WithOperationSpan. The span is the root of a new trace linked to the span of ctx, or its child
+// with GoChildSpan. The goroutine logs "operation started" and "operation finished" at Debug level,
+// or Error level when fn fails, with the "duration" and "status" of the operation.
+// A non-nil error is recorded with RecordSpanError, and a panic is recovered and recorded as a *PanicError.
+//
+// Example:
+//
+//	var tracker xlog.TaskTracker
+//	xlog.Go(ctx, "send-welcome-email", func(ctx context.Context) error {
+//	    return mailer.SendWelcome(ctx, user)
+//	}, xlog.GoTracker(&tracker), xlog.GoFields(xfield.String("user_id", user.ID)))
+//
+//	// on shutdown
+//	tracker.Wait()
+func Go(ctx context.Context, operation string, fn func(ctx context.Context) error, options ...GoOption) {
+	opts := &goOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	var startOptions []trace.SpanStartOption
+	if !opts.child {
+		startOptions = append(startOptions, trace.WithNewRoot())
+		if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+			startOptions = append(startOptions, trace.WithLinks(trace.Link{SpanContext: parent}))
+		}
+	}
+
+	if opts.tracker != nil {
+		opts.tracker.add()
+	}
+	ctx = Detach(ctx)
+	go func() {
+		if opts.tracker != nil {
+			defer opts.tracker.done()
+		}
+		runGoroutine(ctx, operation, fn, opts.fields, startOptions)
+	}()
+}
+
+func runGoroutine(
+	ctx context.Context, operation string, fn func(ctx context.Context) error,
+	fields []xfield.Field, startOptions []trace.SpanStartOption,
+) {
+	ctx, span := startOperationSpan(ctx, operation, fields, startOptions)
+	logWithContext(ctx, loggerFromContext(ctx), DebugLevel, "operation started", withMetadataFields(ctx, nil))
+	start := time.Now()
+
+	var err error
+	defer func() {
+		recovered := recover()
+		level := DebugLevel
+		if err != nil || recovered != nil {
+			level = ErrorLevel
+		}
+		finishTrace(ctx, span, start, err, recovered, level)
+	}()
+
+	err = fn(ctx)
+}
+
+// TaskTracker waits for the goroutines started by Go with GoTracker, e.g. on shutdown.
+// The zero value is ready to use.
+type TaskTracker struct {
+	mu      sync.Mutex
+	running int
+	idle    chan struct{} // closed when running drops to zero
+}
+
+func (t *TaskTracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.running++
+}
+
+func (t *TaskTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running--
+	if t.running == 0 {
+		close(t.idle)
+	}
+}
+
+// idleChan returns a channel closed once no tracked goroutine is running.
+func (t *TaskTracker) idleChan() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running == 0 {
+		return closedChan
+	}
+	return t.idle
+}
+
+// closedChan is an already closed channel.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Wait blocks until the tracked goroutines have returned.
+func (t *TaskTracker) Wait() {
+	<-t.idleChan()
+}
+
+// WaitContext blocks until the tracked goroutines have returned or the context is done,
+// and then returns the error of the context.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := tracker.WaitContext(ctx); err != nil {
+//	    xlog.Warn(ctx, "background tasks still running", xfield.Error(err))
+//	}
+func (t *TaskTracker) WaitContext(ctx context.Context) error {
+	select {
+	case <-t.idleChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,145 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func TestDetach(t *testing.T) {
+	logger, _ := initTestLogger(t)
+	ctx, cancel := context.WithTimeout(ContextWithLogger(context.Background(), logger), time.Minute)
+	cancel()
+
+	detached := Detach(ctx)
+	assert.NoError(t, detached.Err())
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Same(t, logger, LoggerFromContext(detached))
+}
+
+func TestGo(t *testing.T) {
+	t.Run("starts a linked root span with a detached context", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx, parent := WithOperationSpan(ContextWithLogger(context.Background(), logger), "request")
+		ctx, cancel := context.WithCancel(ctx)
+
+		var tracker TaskTracker
+		started := make(chan struct{})
+		Go(ctx, "send-email", func(ctx context.Context) error {
+			<-started
+			assert.NoError(t, ctx.Err())
+			Info(ctx, "sending")
+			return nil
+		}, GoTracker(&tracker), GoFields(xfield.String("user_id", "42")))
+		cancel()
+		parent.End()
+		close(started)
+		tracker.Wait()
+
+		entries := logs.All()
+		require.Len(t, entries, 3)
+		assert.Equal(t, "operation started", entries[0].Message)
+		assert.Equal(t, "sending", entries[1].Message)
+		assert.Equal(t, "request.send-email", entries[1].LoggerName)
+		assert.Equal(t, "42", entries[1].ContextMap()["user_id"])
+		assert.Equal(t, "operation finished", entries[2].Message)
+		assert.Equal(t, zapcore.DebugLevel, entries[2].Level)
+		assert.Equal(t, "ok", entries[2].ContextMap()["status"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		request, task := spans[0], spans[1]
+		assert.Equal(t, "send-email", task.Name())
+		assert.False(t, task.Parent().IsValid())
+		assert.NotEqual(t, request.SpanContext().TraceID(), task.SpanContext().TraceID())
+		require.Len(t, task.Links(), 1)
+		assert.Equal(t, request.SpanContext(), task.Links()[0].SpanContext)
+		assert.Equal(t, codes.Ok, task.Status().Code)
+	})
+
+	t.Run("child span", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		ctx, parent := WithOperationSpan(context.Background(), "request")
+
+		var tracker TaskTracker
+		Go(ctx, "task", func(context.Context) error { return nil }, GoChildSpan(), GoTracker(&tracker))
+		tracker.Wait()
+		parent.End()
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Empty(t, spans[0].Links())
+	})
+
+	t.Run("records errors and recovers panics", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		var tracker TaskTracker
+		Go(ctx, "failing", func(context.Context) error { return errors.New("boom") }, GoTracker(&tracker))
+		tracker.Wait()
+		Go(ctx, "panicking", func(context.Context) error { panic("boom") }, GoTracker(&tracker))
+		tracker.Wait()
+
+		finished := logs.FilterMessage("operation finished").All()
+		require.Len(t, finished, 2)
+		assert.Equal(t, zapcore.ErrorLevel, finished[0].Level)
+		assert.Equal(t, "error", finished[0].ContextMap()["status"])
+		assert.Equal(t, "boom", finished[0].ContextMap()["error"])
+		assert.Equal(t, "panic", finished[1].ContextMap()["status"])
+		assert.Equal(t, "panic: boom", finished[1].ContextMap()["error"])
+		assert.Contains(t, finished[1].ContextMap()["error.stack"], "xlog.TestGo")
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		for _, span := range spans {
+			assert.Equal(t, codes.Error, span.Status().Code)
+			assert.Len(t, span.Events(), 1)
+		}
+	})
+}
+
+func TestTaskTracker(t *testing.T) {
+	var tracker TaskTracker
+	release := make(chan struct{})
+	Go(context.Background(), "blocked", func(context.Context) error {
+		<-release
+		return nil
+	}, GoTracker(&tracker))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, tracker.WaitContext(ctx), context.DeadlineExceeded)
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		require.ErrorIs(t, tracker.WaitContext(ctx), context.DeadlineExceeded)
+	}
+	assert.Equal(t, goroutines, runtime.NumGoroutine(), "abandoned waits must not leave goroutines behind")
+
+	close(release)
+	require.NoError(t, tracker.WaitContext(context.Background()))
+
+	// the tracker is reusable once idle
+	release = make(chan struct{})
+	Go(context.Background(), "blocked", func(context.Context) error {
+		<-release
+		return nil
+	}, GoTracker(&tracker))
+	require.ErrorIs(t, tracker.WaitContext(ctx), context.DeadlineExceeded)
+	close(release)
+	tracker.Wait()
+}
@@ -0,0 +1,153 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// GroupOption is a function that configures NewGroup.
+type GroupOption func(*groupOptions)
+
+type groupOptions struct {
+	fields []xfield.Field
+	limit  int
+}
+
+// GroupFields adds the fields to the logger and as span attributes of the group.
+// The tasks inherit them through the logger.
+func GroupFields(fields ...xfield.Field) GroupOption {
+	return func(o *groupOptions) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// GroupLimit limits the number of tasks running at the same time; Go blocks until a task returns.
+// A limit of zero or less means no limit, the default.
+func GroupLimit(n int) GroupOption {
+	return func(o *groupOptions) {
+		o.limit = n
+	}
+}
+
+// Group runs tasks concurrently like golang.org/x/sync/errgroup, with a span and a named logger
+// per task. It is created by NewGroup and must not be copied.
+type Group struct {
+	ctx    context.Context
+	span   trace.Span
+	cancel context.CancelCauseFunc
+	sem    chan struct{}
+	start  time.Time
+
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	tasks  int
+	errs   []error
+	waited sync.Once
+	err    error
+}
+
+// NewGroup starts a span and a named logger for the operation like WithOperationSpan, and returns
+// a Group whose tasks run as its children. The returned context is canceled when a task fails
+// or Wait returns, whichever comes first.
+//
+// Example:
+//
+//	g, ctx := xlog.NewGroup(ctx, "load-dashboard", xlog.GroupLimit(4))
+//	g.Go("load-user", func(ctx context.Context) error { return loadUser(ctx, id) })
+//	g.Go("load-orders", func(ctx context.Context) error { return loadOrders(ctx, id) })
+//	if err := g.Wait(); err != nil {
+//	    return err
+//	}
+func NewGroup(ctx context.Context, operation string, options ...GroupOption) (*Group, context.Context) {
+	opts := &groupOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	ctx, span := startOperationSpan(ctx, operation, opts.fields, nil)
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{ctx: ctx, span: span, cancel: cancel, start: time.Now()}
+	if opts.limit > 0 {
+		g.sem = make(chan struct{}, opts.limit)
+	}
+	return g, ctx
+}
+
+// Go runs fn in a new goroutine with a child span and a logger named after the task.
+// Errors and panics are recorded on the span of the task, see Trace, and the first one cancels
+// the context of the group. With GroupLimit, Go blocks until the task can start.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.mu.Lock()
+	g.tasks++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		if err := runTask(g.ctx, name, fn); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+			g.cancel(err)
+		}
+	}()
+}
+
+// Wait blocks until all the tasks have returned, then ends the span of the group and returns
+// the errors of the tasks joined with errors.Join, or nil. The span gets the number of tasks
+// and failures as the "group.tasks" and "group.failed" attributes, and an Error status
+// summarizing the failures.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.waited.Do(func() {
+		g.cancel(nil)
+
+		g.mu.Lock()
+		tasks, errs := g.tasks, g.errs
+		g.mu.Unlock()
+
+		g.err = errors.Join(errs...)
+		if g.span.IsRecording() {
+			g.span.SetAttributes(
+				attribute.Int("group.tasks", tasks),
+				attribute.Int("group.failed", len(errs)),
+				fieldToOtelAttribute(xfield.Duration("duration", time.Since(g.start))),
+			)
+			if len(errs) > 0 {
+				g.span.SetStatus(codes.Error, fmt.Sprintf("%d of %d tasks failed", len(errs), tasks))
+			} else {
+				g.span.SetStatus(codes.Ok, "")
+			}
+		}
+		g.span.End()
+	})
+	return g.err
+}
+
+// runTask runs fn in a span for the task and returns its error, or the *PanicError of its panic.
+func runTask(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := startOperationSpan(ctx, name, nil, nil)
+	start := time.Now()
+
+	defer func() {
+		err = finishTrace(ctx, span, start, err, recover(), traceLogLevel())
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,119 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string]sdktrace.ReadOnlySpan {
+	byName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+	return byName
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("runs tasks in child spans with named loggers", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		g, ctx := NewGroup(ctx, "fanout", GroupFields(xfield.String("user_id", "42")))
+		for _, name := range []string{"users", "orders"} {
+			g.Go(name, func(ctx context.Context) error {
+				Info(ctx, "loading")
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+		assert.ErrorIs(t, ctx.Err(), context.Canceled, "Wait cancels the context")
+
+		names := []string{logs.All()[0].LoggerName, logs.All()[1].LoggerName}
+		assert.ElementsMatch(t, []string{"fanout.users", "fanout.orders"}, names)
+		assert.Equal(t, "42", logs.All()[0].ContextMap()["user_id"])
+
+		spans := spansByName(spanRecorder.Ended())
+		require.Len(t, spans, 3)
+		group := spans["fanout"]
+		for _, name := range []string{"users", "orders"} {
+			assert.Equal(t, group.SpanContext().SpanID(), spans[name].Parent().SpanID())
+			assert.Equal(t, codes.Ok, spans[name].Status().Code)
+		}
+		assert.Equal(t, codes.Ok, group.Status().Code)
+		attrs := attribute.NewSet(group.Attributes()...)
+		tasks, _ := attrs.Value("group.tasks")
+		assert.EqualValues(t, 2, tasks.AsInt64())
+	})
+
+	t.Run("cancels siblings and joins the errors", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		errFirst, errSecond := errors.New("first"), errors.New("second")
+
+		g, _ := NewGroup(context.Background(), "fanout")
+		g.Go("first", func(context.Context) error { return errFirst })
+		g.Go("second", func(ctx context.Context) error {
+			<-ctx.Done()
+			assert.ErrorIs(t, context.Cause(ctx), errFirst)
+			return errSecond
+		})
+		g.Go("third", func(ctx context.Context) error {
+			<-ctx.Done()
+			panic("boom")
+		})
+		err := g.Wait()
+
+		require.ErrorIs(t, err, errFirst)
+		require.ErrorIs(t, err, errSecond)
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.Same(t, err, g.Wait(), "Wait returns the same error")
+
+		spans := spansByName(spanRecorder.Ended())
+		require.Len(t, spans, 4)
+		for _, name := range []string{"first", "second", "third"} {
+			assert.Equal(t, codes.Error, spans[name].Status().Code, name)
+			assert.Len(t, spans[name].Events(), 1, name)
+		}
+		group := spans["fanout"]
+		assert.Equal(t, codes.Error, group.Status().Code)
+		assert.Equal(t, "3 of 3 tasks failed", group.Status().Description)
+		assert.Empty(t, group.Events())
+		attrs := attribute.NewSet(group.Attributes()...)
+		failedTasks, _ := attrs.Value("group.failed")
+		assert.EqualValues(t, 3, failedTasks.AsInt64())
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		setupTestTracer(t)
+
+		var running, maxRunning atomic.Int32
+		g, _ := NewGroup(context.Background(), "fanout", GroupLimit(2))
+		for range 10 {
+			g.Go("task", func(context.Context) error {
+				n := running.Add(1)
+				for {
+					current := maxRunning.Load()
+					if n <= current || maxRunning.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				running.Add(-1)
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+}
@@ -0,0 +1,149 @@
+package xlog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const (
+	// LevelHeader is the default request header used by LevelTrigger.
+	LevelHeader = "X-Xlog-Level"
+	// LevelBaggageMember is the default baggage member used by LevelTrigger.
+	LevelBaggageMember = "xlog-debug"
+)
+
+// WithLevel returns a context whose logger uses the given minimum level,
+// regardless of the level configured on the underlying zap or slog logger.
+// The level sticks to the context: loggers attached later with ContextWithLogger,
+// WithOperation, WithFields or WithOperationSpan also use it.
+// Loggers that don't implement LevelOverrider are left unchanged.
+//
+// Example:
+//
+//	ctx = xlog.WithLevel(ctx, xlog.DebugLevel)
+//	xlog.Debug(ctx, "visible even if the backend is at Info")
+func WithLevel(ctx context.Context, level Level) context.Context {
+	ctx = context.WithValue(ctx, levelCtxKey, level)
+	return ContextWithLogger(ctx, loggerFromContext(ctx))
+}
+
+// LevelFromContext returns the level set by WithLevel.
+// The boolean is false if no level was set.
+func LevelFromContext(ctx context.Context) (Level, bool) {
+	return levelFromContext(ctx)
+}
+
+func levelFromContext(ctx context.Context) (Level, bool) {
+	level, ok := ctx.Value(levelCtxKey).(Level)
+	return level, ok
+}
+
+func overrideLevel(logger Logger, level Level) Logger {
+	if overrider, ok := logger.(LevelOverrider); ok {
+		return overrider.WithLevelOverride(level)
+	}
+	return logger
+}
+
+// LevelTrigger describes how an inbound request asks for a different log level.
+// The value of the header or baggage member is either a level name ("debug", "warn", ...)
+// or a boolean flag ("1", "true"), which selects DebugLevel.
+//
+// A trigger can only make logging more verbose: levels that would raise the level of
+// the logger of the request context are ignored, so a caller can't silence the errors of
+// its requests. Triggers still let any caller increase the volume of logs,
+// so enable them only for trusted traffic.
+type LevelTrigger struct {
+	// Header is the request header to look at. Empty disables the header trigger.
+	Header string
+	// BaggageMember is the W3C baggage member to look at. Empty disables the baggage trigger.
+	BaggageMember string
+}
+
+// DefaultLevelTrigger returns a trigger using LevelHeader and LevelBaggageMember.
+func DefaultLevelTrigger() LevelTrigger {
+	return LevelTrigger{
+		Header:        LevelHeader,
+		BaggageMember: LevelBaggageMember,
+	}
+}
+
+// LevelFromRequest returns the level requested by the request according to the trigger.
+// The baggage is taken from the request context if it was already extracted by
+// a propagator, otherwise it is parsed from the "baggage" header.
+// The boolean is false if the request doesn't ask for a level, or asks for a level that would
+// raise the level of the logger of the request context, see raisesLevel.
+func (t LevelTrigger) LevelFromRequest(r *http.Request) (Level, bool) {
+	level, ok := t.requestedLevel(r)
+	if !ok || raisesLevel(loggerFromContext(r.Context()), level) {
+		return InfoLevel, false
+	}
+	return level, true
+}
+
+func (t LevelTrigger) requestedLevel(r *http.Request) (Level, bool) {
+	if t.Header != "" {
+		if level, ok := parseTriggerValue(r.Header.Get(t.Header)); ok {
+			return level, true
+		}
+	}
+
+	if t.BaggageMember != "" {
+		member := baggage.FromContext(r.Context()).Member(t.BaggageMember)
+		if member.Key() == "" {
+			if bag, err := baggage.Parse(r.Header.Get("baggage")); err == nil {
+				member = bag.Member(t.BaggageMember)
+			}
+		}
+		if level, ok := parseTriggerValue(member.Value()); ok {
+			return level, true
+		}
+	}
+
+	return InfoLevel, false
+}
+
+// Middleware returns an HTTP middleware that applies the level requested by
+// the request to its context with WithLevel.
+//
+// Example:
+//
+//	handler = xlog.DefaultLevelTrigger().Middleware(handler)
+func (t LevelTrigger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if level, ok := t.LevelFromRequest(r); ok {
+			r = r.WithContext(WithLevel(r.Context(), level))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// raisesLevel reports whether using level would hide entries the logger writes.
+// For the loggers that don't implement LevelEnabler, only DebugLevel and InfoLevel are allowed.
+func raisesLevel(logger Logger, level Level) bool {
+	if le, ok := logger.(LevelEnabler); ok {
+		return level > DebugLevel && le.Enabled(level-1)
+	}
+	return level > InfoLevel
+}
+
+func parseTriggerValue(value string) (Level, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return InfoLevel, false
+	}
+
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		return DebugLevel, enabled
+	}
+
+	level, err := ParseLevel(value)
+	if err != nil {
+		return InfoLevel, false
+	}
+	return level, true
+}
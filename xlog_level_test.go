@@ -0,0 +1,235 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func initLeveledZapLogger(t *testing.T, level zapcore.Level) (Logger, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(level)
+	return NewZapAdapter(zap.New(core)), logs
+}
+
+func TestWithLevel(t *testing.T) {
+	t.Run("lowers level of zap adapter", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		Debug(ctx, "hidden")
+		require.Equal(t, 0, logs.Len())
+
+		ctx = WithLevel(ctx, DebugLevel)
+		Debug(ctx, "visible", xfield.String("key", "value"))
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.DebugLevel, entry.Level)
+		assert.Equal(t, "visible", entry.Message)
+		assert.Equal(t, "value", entry.ContextMap()["key"])
+	})
+
+	t.Run("raises level of zap adapter", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.DebugLevel)
+		ctx := WithLevel(ContextWithLogger(context.Background(), logger), ErrorLevel)
+
+		Info(ctx, "hidden")
+		Error(ctx, "visible")
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "visible", logs.All()[0].Message)
+	})
+
+	t.Run("level survives derived loggers", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		ctx := WithLevel(ContextWithLogger(context.Background(), logger), DebugLevel)
+
+		ctx = WithOperation(ctx, "operation", xfield.String("key", "value"))
+		ctx = WithFields(ctx, xfield.Int("count", 1))
+		Debug(ctx, "visible")
+
+		// A logger attached later also honors the context level.
+		other, otherLogs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		Debug(ContextWithLogger(ctx, other), "visible too")
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, "operation", entry.LoggerName)
+		assert.Equal(t, "value", entry.ContextMap()["key"])
+		assert.Equal(t, int64(1), entry.ContextMap()["count"])
+		require.Equal(t, 1, otherLogs.Len())
+	})
+
+	t.Run("repeated overrides replace each other", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx = WithLevel(ctx, DebugLevel)
+		ctx = WithLevel(ctx, WarnLevel)
+		Info(ctx, "hidden")
+		Warn(ctx, "visible")
+
+		require.Equal(t, 1, logs.Len())
+		level, ok := LevelFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, WarnLevel, level)
+	})
+
+	t.Run("does not affect parent context", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		_ = WithLevel(ctx, DebugLevel)
+		Debug(ctx, "hidden")
+
+		assert.Equal(t, 0, logs.Len())
+		_, ok := LevelFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("lowers level of slog adapter", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+		ctx := ContextWithLogger(context.Background(), NewSlogAdapter(slog.New(handler)))
+
+		Debug(ctx, "hidden")
+		assert.Empty(t, buf.String())
+
+		ctx = WithLevel(ctx, DebugLevel)
+		ctx = WithOperation(ctx, "operation")
+		Debug(ctx, "visible")
+
+		assert.Contains(t, buf.String(), `"msg":"visible"`)
+		assert.Contains(t, buf.String(), `"logger":"operation"`)
+	})
+
+	t.Run("ignores loggers without override support", func(t *testing.T) {
+		ctx := ContextWithLogger(context.Background(), NewNoopLogger())
+
+		ctx = WithLevel(ctx, DebugLevel)
+		assert.IsType(t, &NoopLogger{}, LoggerFromContext(ctx))
+	})
+}
+
+func TestLevelTrigger(t *testing.T) {
+	trigger := DefaultLevelTrigger()
+
+	t.Run("level from header", func(t *testing.T) {
+		for value, expected := range map[string]Level{
+			"debug": DebugLevel,
+			"INFO":  InfoLevel,
+			"1":     DebugLevel,
+			"true":  DebugLevel,
+		} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(LevelHeader, value)
+
+			level, ok := trigger.LevelFromRequest(r)
+			require.True(t, ok, value)
+			assert.Equal(t, expected, level, value)
+		}
+	})
+
+	t.Run("ignores disabled and invalid values", func(t *testing.T) {
+		for _, value := range []string{"", "0", "false", "verbose"} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(LevelHeader, value)
+
+			_, ok := trigger.LevelFromRequest(r)
+			assert.False(t, ok, value)
+		}
+	})
+
+	t.Run("ignores levels raising the level of the logger", func(t *testing.T) {
+		logger, _ := initLeveledZapLogger(t, zapcore.WarnLevel)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		for value, expected := range map[string]bool{
+			"debug": true,
+			"info":  true,
+			"warn":  true,
+			"error": false,
+			"fatal": false,
+		} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+			r.Header.Set(LevelHeader, value)
+
+			_, ok := trigger.LevelFromRequest(r)
+			assert.Equal(t, expected, ok, value)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		r.Header.Set("baggage", "xlog-debug=fatal")
+		_, ok := trigger.LevelFromRequest(r)
+		assert.False(t, ok, "baggage")
+
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(LevelHeader, "warn")
+		_, ok = trigger.LevelFromRequest(r)
+		assert.False(t, ok, "loggers that can't tell their level only accept debug and info")
+	})
+
+	t.Run("level from baggage header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("baggage", "tenant=acme,xlog-debug=1")
+
+		level, ok := trigger.LevelFromRequest(r)
+		require.True(t, ok)
+		assert.Equal(t, DebugLevel, level)
+	})
+
+	t.Run("level from extracted baggage", func(t *testing.T) {
+		member, err := baggage.NewMember(LevelBaggageMember, "debug")
+		require.NoError(t, err)
+		bag, err := baggage.New(member)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(baggage.ContextWithBaggage(r.Context(), bag))
+
+		level, ok := trigger.LevelFromRequest(r)
+		require.True(t, ok)
+		assert.Equal(t, DebugLevel, level)
+	})
+
+	t.Run("disabled sources are ignored", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(LevelHeader, "debug")
+		r.Header.Set("baggage", "xlog-debug=1")
+
+		_, ok := LevelTrigger{}.LevelFromRequest(r)
+		assert.False(t, ok)
+	})
+
+	t.Run("middleware applies level", func(t *testing.T) {
+		logger, logs := initLeveledZapLogger(t, zapcore.InfoLevel)
+		restore := ReplaceGlobalLogger(logger)
+		defer restore()
+
+		handler := trigger.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			Debug(r.Context(), "debug from handler")
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, 0, logs.Len())
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(LevelHeader, "debug")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		require.Equal(t, 1, logs.Len())
+	})
+}
@@ -0,0 +1,160 @@
+package xlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// recoveredMessage is the default message of the entries logged by Recover and RecoverAndReturn.
+const recoveredMessage = "panic recovered"
+
+// PanicError is the error built from a recovered panic by Recover, RecoverAndReturn and Trace.
+// It unwraps to the panic value when the value is an error.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	stack []uintptr
+}
+
+// newPanicError builds the error of the recovered value with the stack of the panicking goroutine,
+// starting at the panicking function: the frames of the deferred functions and of the runtime
+// raising the panic are dropped.
+func newPanicError(value any, skip int) *PanicError {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+3, pcs) // skip runtime.Callers, newPanicError and its caller
+	pcs = pcs[:n]
+	for i, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			break
+		}
+	}
+	// runtime errors are raised by more runtime frames, e.g. runtime.panicmem and runtime.sigpanic
+	for len(pcs) > 0 {
+		fn := runtime.FuncForPC(pcs[0] - 1)
+		if fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+			break
+		}
+		pcs = pcs[1:]
+	}
+	return &PanicError{Value: value, stack: pcs}
+}
+
+// Error returns "panic: " followed by the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, otherwise nil.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Stack returns the stack of the panic, formatted like the stack of NewErr.
+func (e *PanicError) Stack() string {
+	return xfield.FormatStack(e.stack)
+}
+
+// Format prints the message and, with %+v, the stack trace.
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	_, _ = io.WriteString(s, e.Error())
+	if verb == 'v' && s.Flag('+') && len(e.stack) > 0 {
+		_, _ = io.WriteString(s, "\n"+e.Stack())
+	}
+}
+
+// RecoverOption is a function that configures Recover and RecoverAndReturn.
+type RecoverOption func(*recoverOptions)
+
+type recoverOptions struct {
+	message     string
+	repanic     bool
+	passThrough func(value any) bool
+}
+
+// RecoverMessage sets the message of the entry logged for the panic. The default is "panic recovered".
+func RecoverMessage(msg string) RecoverOption {
+	return func(o *recoverOptions) {
+		o.message = msg
+	}
+}
+
+// Repanic makes Recover and RecoverAndReturn panic again with the same value once the panic is logged,
+// e.g. to let an outer handler or the runtime deal with it.
+func Repanic() RecoverOption {
+	return func(o *recoverOptions) {
+		o.repanic = true
+	}
+}
+
+// PassThrough makes Recover and RecoverAndReturn panic again with the values fn reports true for,
+// without logging them or marking the span, e.g. sentinels such as http.ErrAbortHandler
+// meant for an outer handler.
+func PassThrough(fn func(value any) bool) RecoverOption {
+	return func(o *recoverOptions) {
+		o.passThrough = fn
+	}
+}
+
+// Recover recovers a panic and logs it at Error level with the logger from the context:
+// the "error" field holds a *PanicError and its details include the stack of the panic
+// ("error.stack"). The span of the context is marked as failed with an exception event
+// carrying the panic stack. Recover must be deferred directly, as recover only stops a panic there.
+//
+// Example:
+//
+//	go func() {
+//	    defer xlog.Recover(ctx)
+//	    worker.Run(ctx)
+//	}()
+func Recover(ctx context.Context, options ...RecoverOption) {
+	if recovered := recover(); recovered != nil {
+		handlePanic(ctx, recovered, options)
+	}
+}
+
+// RecoverAndReturn recovers and logs a panic like Recover, and stores the *PanicError in errp,
+// so the function returns it as an error. It must be deferred directly.
+//
+// Example:
+//
+//	func (h *Handler) process(ctx context.Context, msg Message) (err error) {
+//	    defer xlog.RecoverAndReturn(ctx, &err)
+//	    return h.handle(ctx, msg)
+//	}
+func RecoverAndReturn(ctx context.Context, errp *error, options ...RecoverOption) {
+	if recovered := recover(); recovered != nil {
+		err := handlePanic(ctx, recovered, options)
+		if errp != nil {
+			*errp = err
+		}
+	}
+}
+
+// handlePanic logs the recovered value and marks the span as failed.
+// It must be called directly by Recover and RecoverAndReturn.
+func handlePanic(ctx context.Context, recovered any, options []RecoverOption) error {
+	opts := &recoverOptions{message: recoveredMessage}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.passThrough != nil && opts.passThrough(recovered) {
+		panic(recovered)
+	}
+
+	err := newPanicError(recovered, 1) // skip Recover or RecoverAndReturn
+	fields := []xfield.Field{xfield.Error(err)}
+	markSpanError(ctx, opts.message, fields, "")
+	logWithContext(ctx, loggerFromContext(ctx), ErrorLevel, opts.message, withMetadataFields(ctx, fields))
+
+	if opts.repanic {
+		panic(recovered)
+	}
+	return err
+}
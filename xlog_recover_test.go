@@ -0,0 +1,222 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("logs the panic and marks the span", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx, span := WithOperationSpan(ContextWithLogger(context.Background(), logger), "worker")
+
+		assert.NotPanics(t, func() {
+			defer Recover(ctx)
+			panic("boom")
+		})
+		span.End()
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+		assert.Equal(t, "panic recovered", entry.Message)
+		fields := entry.ContextMap()
+		assert.Equal(t, "panic: boom", fields["error"])
+		assert.Equal(t, "*xlog.PanicError", fields["error.type"])
+		assert.Contains(t, fields["error.stack"], "xlog.TestRecover")
+		assert.Contains(t, fields, "trace_id")
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Len(t, spans[0].Events(), 1)
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+		event := attribute.NewSet(spans[0].Events()[0].Attributes...)
+		exceptionType, _ := event.Value("exception.type")
+		assert.Equal(t, "*xlog.PanicError", exceptionType.AsString())
+		stack, _ := event.Value("exception.stacktrace")
+		assert.Equal(t, fields["error.stack"], stack.AsString())
+	})
+
+	t.Run("message option and no panic", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		func() {
+			defer Recover(ctx, RecoverMessage("worker crashed"))
+			panic(fs.ErrClosed)
+		}()
+		func() {
+			defer Recover(ctx)
+		}()
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "worker crashed", logs.All()[0].Message)
+	})
+
+	t.Run("repanics with the same value", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			defer Recover(ctx, Repanic())
+			panic("boom")
+		})
+		assert.Equal(t, 1, logs.Len())
+	})
+
+	t.Run("passes through values without logging them", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+		isAbort := func(value any) bool { return value == "abort" }
+
+		assert.PanicsWithValue(t, "abort", func() {
+			defer Recover(ctx, PassThrough(isAbort))
+			panic("abort")
+		})
+		assert.Zero(t, logs.Len())
+
+		func() {
+			defer Recover(ctx, PassThrough(isAbort))
+			panic("boom")
+		}()
+		assert.Equal(t, 1, logs.Len())
+	})
+}
+
+func TestRecoverAndReturn(t *testing.T) {
+	logger, logs := initTestLogger(t)
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	process := func(value any) (err error) {
+		defer RecoverAndReturn(ctx, &err)
+		if value != nil {
+			panic(value)
+		}
+		return nil
+	}
+
+	require.NoError(t, process(nil))
+
+	err := process(fs.ErrClosed)
+	require.EqualError(t, err, "panic: file already closed")
+	assert.ErrorIs(t, err, fs.ErrClosed)
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, fs.ErrClosed, panicErr.Value)
+	assert.Contains(t, panicErr.Stack(), "xlog.TestRecoverAndReturn")
+	assert.Contains(t, fmt.Sprintf("%+v", err), "panic: file already closed\ngithub.com/ruko1202/xlog.TestRecoverAndReturn.func1\n")
+
+	err = process(42)
+	require.EqualError(t, err, "panic: 42")
+	assert.Nil(t, errors.Unwrap(err))
+
+	assert.Equal(t, 2, logs.Len())
+}
+
+//go:noinline
+func panicBoom() {
+	panic("boom")
+}
+
+//go:noinline
+func panicNilPointer() {
+	var p *int
+	*p++
+}
+
+func TestPanicErrorStack(t *testing.T) {
+	const panicking = "github.com/ruko1202/xlog.panicBoom"
+	firstFrame := func(stack string) string {
+		function, _, _ := strings.Cut(stack, "\n")
+		return function
+	}
+	exceptionStack := func(t *testing.T, span sdktrace.ReadOnlySpan) string {
+		t.Helper()
+		require.Len(t, span.Events(), 1)
+		event := attribute.NewSet(span.Events()[0].Attributes...)
+		stack, ok := event.Value("exception.stacktrace")
+		require.True(t, ok)
+		return stack.AsString()
+	}
+
+	t.Run("Recover", func(t *testing.T) {
+		initTestLogger(t)
+		var err error
+		func() {
+			defer RecoverAndReturn(context.Background(), &err)
+			panicBoom()
+		}()
+
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, panicking, firstFrame(panicErr.Stack()))
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		initTestLogger(t)
+		var err error
+		func() {
+			defer RecoverAndReturn(context.Background(), &err)
+			panicNilPointer()
+		}()
+
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "github.com/ruko1202/xlog.panicNilPointer", firstFrame(panicErr.Stack()))
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		assert.Panics(t, func() {
+			_ = Trace(context.Background(), "op", func(context.Context) error {
+				panicBoom()
+				return nil
+			})
+		})
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, panicking, firstFrame(exceptionStack(t, spans[0])))
+	})
+
+	t.Run("Go", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		initTestLogger(t)
+		var tracker TaskTracker
+		Go(context.Background(), "op", func(context.Context) error {
+			panicBoom()
+			return nil
+		}, GoTracker(&tracker))
+		tracker.Wait()
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, panicking, firstFrame(exceptionStack(t, spans[0])))
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		setupTestTracer(t)
+		g, _ := NewGroup(context.Background(), "group")
+		g.Go("task", func(context.Context) error {
+			panicBoom()
+			return nil
+		})
+
+		var panicErr *PanicError
+		require.ErrorAs(t, g.Wait(), &panicErr)
+		assert.Equal(t, panicking, firstFrame(panicErr.Stack()))
+	})
+}
@@ -0,0 +1,400 @@
+package xsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+var (
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+	_ driver.ColumnConverter   = columnConverterStmt{} //nolint:staticcheck // forwarded for the drivers still using it
+
+	_ driver.RowsNextResultSet              = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeLength           = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*rows)(nil)
+)
+
+// errNamedArgs is returned when named arguments are passed to a driver without context support.
+var errNamedArgs = errors.New("xsql: driver does not support the use of Named Parameters")
+
+// conn traces the operations of a driver.Conn. The optional interfaces of database/sql are
+// implemented with the default behavior of database/sql when the wrapped connection lacks them.
+type conn struct {
+	driver.Conn
+	opts *options
+}
+
+func newConn(c driver.Conn, opts *options) *conn {
+	return &conn{Conn: c, opts: opts}
+}
+
+// PrepareContext prepares a statement in a "PREPARE" span.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ctx, span := c.opts.startSpan(ctx, "PREPARE", query)
+
+	var (
+		s   driver.Stmt
+		err error
+	)
+	if cp, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = cp.PrepareContext(ctx, query)
+	} else if s, err = c.Conn.Prepare(query); err == nil && ctx.Err() != nil {
+		_ = s.Close()
+		s, err = nil, ctx.Err()
+	}
+	span.end(err)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := &stmt{Stmt: s, conn: c.Conn, query: query, opts: c.opts}
+	if _, ok := s.(driver.ColumnConverter); ok { //nolint:staticcheck // forwarded for the drivers still using it
+		return columnConverterStmt{wrapped}, nil
+	}
+	return wrapped, nil
+}
+
+// BeginTx starts a transaction in a "BEGIN" span.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	spanCtx, span := c.opts.startSpan(ctx, "BEGIN", "")
+
+	var (
+		t   driver.Tx
+		err error
+	)
+	if cb, ok := c.Conn.(driver.ConnBeginTx); ok {
+		t, err = cb.BeginTx(spanCtx, opts)
+	} else {
+		t, err = beginWithoutContext(spanCtx, c.Conn, opts)
+	}
+	span.end(err)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, ctx: ctx, opts: c.opts}, nil
+}
+
+// ExecContext executes a statement in a span, or returns driver.ErrSkip
+// for database/sql to prepare it when the wrapped connection can't execute it directly.
+// The span is started once the statement returns, so none is left when the wrapped connection
+// returns driver.ErrSkip, and the context of the driver doesn't hold it.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	_, span := c.opts.startSpanAt(ctx, "", query, start)
+	span.end(err, resultAttributes(result, err)...)
+	return result, err
+}
+
+// QueryContext executes a query in a span ended when the rows are closed, or returns driver.ErrSkip
+// for database/sql to prepare it when the wrapped connection can't execute it directly.
+// The span is started once the query returns, like ExecContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	r, err := queryer.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	_, span := c.opts.startSpanAt(ctx, "", query, start)
+	if err != nil {
+		span.end(err)
+		return nil, err
+	}
+	return &rows{Rows: r, span: span}, nil
+}
+
+// Ping checks the connection if the wrapped connection supports it.
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the session if the wrapped connection supports it.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the connection can be reused, true if the wrapped connection can't tell.
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue checks the arguments with the wrapped connection, or the default converter.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt traces the executions of a prepared statement.
+type stmt struct {
+	driver.Stmt
+	conn  driver.Conn
+	query string
+	opts  *options
+}
+
+// ExecContext executes the statement in a span.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := s.opts.startSpan(ctx, "", s.query)
+
+	var (
+		result driver.Result
+		err    error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				result, err = s.Exec(values) //nolint:staticcheck // fallback of the drivers without context support
+			}
+		}
+	}
+	span.end(err, resultAttributes(result, err)...)
+	return result, err
+}
+
+// QueryContext executes the query in a span ended when the rows are closed.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := s.opts.startSpan(ctx, "", s.query)
+
+	var (
+		r   driver.Rows
+		err error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		r, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				r, err = s.Query(values) //nolint:staticcheck // fallback of the drivers without context support
+			}
+		}
+	}
+	if err != nil {
+		span.end(err)
+		return nil, err
+	}
+	return &rows{Rows: r, span: span}, nil
+}
+
+// CheckNamedValue checks the arguments with the wrapped statement, or the wrapped connection,
+// as database/sql does when the statement has no checker.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// columnConverterStmt is a stmt wrapping a statement implementing driver.ColumnConverter.
+// It is a separate type because database/sql calls the converter whenever a statement implements it.
+type columnConverterStmt struct {
+	*stmt
+}
+
+// ColumnConverter returns the converter of the wrapped statement.
+func (s columnConverterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.Stmt.(driver.ColumnConverter).ColumnConverter(idx) //nolint:staticcheck // forwarded for the drivers still using it
+}
+
+// tx traces the end of a transaction, in the context it was started with.
+type tx struct {
+	driver.Tx
+	ctx  context.Context
+	opts *options
+}
+
+// Commit commits the transaction in a "COMMIT" span.
+func (t *tx) Commit() error {
+	_, span := t.opts.startSpan(t.ctx, "COMMIT", "")
+	err := t.Tx.Commit()
+	span.end(err)
+	return err
+}
+
+// Rollback aborts the transaction in a "ROLLBACK" span.
+func (t *tx) Rollback() error {
+	_, span := t.opts.startSpan(t.ctx, "ROLLBACK", "")
+	err := t.Tx.Rollback()
+	span.end(err)
+	return err
+}
+
+// rows counts the rows returned by a query and ends its span when closed.
+type rows struct {
+	driver.Rows
+	span     *dbSpan
+	returned int
+	err      error
+	closed   bool
+}
+
+// Next reads the next row, recording the error ending the iteration.
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.returned++
+	case !errors.Is(err, io.EOF):
+		r.err = err
+	}
+	return err
+}
+
+// Close closes the rows and ends the span of the query.
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.span.end(errors.Join(r.err, err), semconv.DBResponseReturnedRows(r.returned))
+	}
+	return err
+}
+
+// HasNextResultSet reports whether there is another result set, false if the wrapped rows can't tell.
+func (r *rows) HasNextResultSet() bool {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.HasNextResultSet()
+	}
+	return false
+}
+
+// NextResultSet advances to the next result set, io.EOF if the wrapped rows don't support it.
+func (r *rows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+// ColumnTypeScanType returns the scan type of a column, any if the wrapped rows can't tell.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeFor[any]()
+}
+
+// ColumnTypeDatabaseTypeName returns the database type of a column, empty if the wrapped rows can't tell.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeLength returns the length of a column if the wrapped rows can tell.
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return typed.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+// ColumnTypeNullable reports whether a column may be null if the wrapped rows can tell.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return typed.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+// ColumnTypePrecisionScale returns the precision and the scale of a column if the wrapped rows can tell.
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return typed.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+// beginWithoutContext starts a transaction with a driver without driver.ConnBeginTx, like database/sql.
+func beginWithoutContext(ctx context.Context, c driver.Conn, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("xsql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("xsql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t, err := c.Begin() //nolint:staticcheck // fallback of the drivers without context support
+	if err == nil && ctx.Err() != nil {
+		_ = t.Rollback()
+		return nil, ctx.Err()
+	}
+	return t, err
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// resultAttributes returns the rows affected by a statement, if the driver tells.
+func resultAttributes(result driver.Result, err error) []attribute.KeyValue {
+	if err != nil || result == nil {
+		return nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil
+	}
+	return []attribute.KeyValue{affectedRowsKey.Int64(affected)}
+}
@@ -0,0 +1,256 @@
+package xsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ruko1202/xlog"
+)
+
+// custom is an argument type the default converter of database/sql rejects.
+type custom struct{}
+
+// customConverter converts custom arguments to strings.
+type customConverter struct{}
+
+func (customConverter) ConvertValue(v any) (driver.Value, error) {
+	if _, ok := v.(custom); ok {
+		return "custom", nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// checkingConn accepts custom arguments in CheckNamedValue, like the connections of pgx.
+type checkingConn struct {
+	*fakeConn
+}
+
+func (checkingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(custom); ok {
+		nv.Value = "custom"
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// convertingConn prepares statements converting custom arguments with a driver.ColumnConverter.
+type convertingConn struct {
+	*fakeConn
+}
+
+func (c convertingConn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.fakeConn.Prepare(query)
+	return convertingStmt{s.(*fakeStmt)}, err
+}
+
+type convertingStmt struct {
+	*fakeStmt
+}
+
+func (convertingStmt) ColumnConverter(int) driver.ValueConverter {
+	return customConverter{}
+}
+
+// wrappingConnector wraps the connections of a fakeConnector.
+type wrappingConnector struct {
+	fakeConnector
+	wrap func(*fakeConn) driver.Conn
+}
+
+func (c wrappingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.fakeConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrap(conn.(*fakeConn)), nil
+}
+
+func TestConn(t *testing.T) {
+	t.Run("prepared statements", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{}})
+		defer db.Close()
+
+		stmt, err := db.PrepareContext(context.Background(), "UPDATE users SET name = ? WHERE id = ?")
+		require.NoError(t, err)
+		_, err = stmt.ExecContext(context.Background(), "bob", 1)
+		require.NoError(t, err)
+		_, err = stmt.ExecContext(context.Background(), sql.Named("name", "bob"))
+		require.Error(t, err, "the legacy statements don't support named arguments")
+		require.NoError(t, stmt.Close())
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 3)
+		assert.Equal(t, "PREPARE", spans[0].Name())
+		assert.Equal(t, "UPDATE", spans[1].Name())
+		assert.Equal(t, int64(1), spanAttributes(spans[1])["db.response.affected_rows"].AsInt64())
+		assert.Equal(t, codes.Error, spans[2].Status().Code)
+	})
+
+	t.Run("statements skipped by the driver are prepared without an extra span", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{rows: [][]driver.Value{{int64(1), "bob"}}}})
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "DELETE FROM users /* prepare */")
+		require.NoError(t, err)
+		rows, err := db.QueryContext(context.Background(), "SELECT id, name FROM users /* prepare */")
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+
+		var names []string
+		for _, span := range spanRecorder.Ended() {
+			names = append(names, span.Name())
+		}
+		assert.Equal(t, []string{"PREPARE", "DELETE", "PREPARE", "SELECT"}, names)
+	})
+
+	t.Run("arguments are checked by the connection when the statement has no checker", func(t *testing.T) {
+		setupTestTracer(t)
+		db := OpenDB(wrappingConnector{
+			fakeConnector: fakeConnector{driver: &fakeDriver{}},
+			wrap:          func(c *fakeConn) driver.Conn { return checkingConn{c} },
+		})
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "UPDATE users SET name = ?", custom{})
+		require.NoError(t, err)
+		_, err = db.ExecContext(context.Background(), "UPDATE users SET name = ? /* prepare */", custom{})
+		require.NoError(t, err)
+		stmt, err := db.PrepareContext(context.Background(), "UPDATE users SET name = ?")
+		require.NoError(t, err)
+		defer stmt.Close()
+		_, err = stmt.ExecContext(context.Background(), custom{})
+		require.NoError(t, err)
+	})
+
+	t.Run("column converters of statements are forwarded", func(t *testing.T) {
+		setupTestTracer(t)
+		db := OpenDB(wrappingConnector{
+			fakeConnector: fakeConnector{driver: &fakeDriver{}},
+			wrap:          func(c *fakeConn) driver.Conn { return convertingConn{c} },
+		})
+		defer db.Close()
+
+		stmt, err := db.PrepareContext(context.Background(), "UPDATE users SET name = ?")
+		require.NoError(t, err)
+		defer stmt.Close()
+		_, err = stmt.ExecContext(context.Background(), custom{})
+		require.NoError(t, err)
+
+		plain, err := db.PrepareContext(context.Background(), "UPDATE users SET name = ?")
+		require.NoError(t, err)
+		defer plain.Close()
+		_, err = plain.ExecContext(context.Background(), "bob")
+		require.NoError(t, err)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{}})
+		defer db.Close()
+
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "DELETE FROM users")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		tx, err = db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		_, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		require.Error(t, err, "the legacy transactions can't be read-only")
+		parent.End()
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 7)
+		var names []string
+		for _, span := range spans[:6] {
+			names = append(names, span.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+		}
+		assert.Equal(t, []string{"BEGIN", "DELETE", "COMMIT", "BEGIN", "ROLLBACK", "BEGIN"}, names)
+		assert.Equal(t, codes.Error, spans[5].Status().Code)
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logs := initTestLogger(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{}}, WithSlowThreshold(time.Hour))
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "INSERT INTO fail VALUES (1)")
+		require.ErrorIs(t, err, errFake)
+		_, err = db.QueryContext(context.Background(), "SELECT * FROM fail")
+		require.ErrorIs(t, err, errFake)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		for _, span := range spans {
+			assert.Equal(t, codes.Error, span.Status().Code)
+			assert.Equal(t, errFake.Error(), span.Status().Description)
+			require.Len(t, span.Events(), 1)
+			assert.Equal(t, "exception", span.Events()[0].Name)
+		}
+		assert.Zero(t, logs.Len(), "only slow queries are logged")
+	})
+
+	t.Run("logs slow queries", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logs := initTestLogger(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{}},
+			WithSlowThreshold(10*time.Millisecond),
+			WithSanitizer(SanitizeQuery),
+		)
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "UPDATE slow SET name = 'bob' WHERE id = 42")
+		require.NoError(t, err)
+		_, err = db.ExecContext(context.Background(), "UPDATE fast SET name = 'bob'")
+		require.NoError(t, err)
+		_, err = db.ExecContext(context.Background(), "DELETE FROM slow_fail")
+		require.ErrorIs(t, err, errFake)
+
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "slow query", entries[0].Message)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "UPDATE", fields["operation"])
+		assert.Equal(t, "UPDATE slow SET name = ? WHERE id = ?", fields["query"])
+		assert.GreaterOrEqual(t, fields["duration"], 10*time.Millisecond)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 3)
+		assert.Equal(t, "UPDATE slow SET name = ? WHERE id = ?", spanAttributes(spans[0])["db.query.text"].AsString())
+		assert.Equal(t, spans[0].SpanContext().TraceID().String(), fields["trace_id"])
+		assert.Equal(t, errFake.Error(), entries[1].ContextMap()["error"])
+		assert.Len(t, spans[2].Events(), 1, "the error is recorded once")
+	})
+
+	t.Run("slow query log can be disabled", func(t *testing.T) {
+		setupTestTracer(t)
+		logs := initTestLogger(t)
+		db := OpenDB(fakeConnector{driver: &fakeDriver{}},
+			WithSlowThreshold(time.Nanosecond),
+			WithSlowLogLevel(xlog.FatalLevel+1),
+		)
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "UPDATE slow SET x = 1")
+		require.NoError(t, err)
+		assert.Zero(t, logs.Len())
+	})
+}
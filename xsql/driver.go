@@ -0,0 +1,261 @@
+// Package xsql traces and logs database/sql drivers with xlog.
+package xsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog"
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// affectedRowsKey is the attribute of the rows affected by a statement,
+// not defined by the semantic conventions.
+const affectedRowsKey = attribute.Key("db.response.affected_rows")
+
+// The attributes of the semantic conventions before v1.26, still read by many backends.
+const (
+	legacySystemKey    = attribute.Key("db.system")
+	legacyStatementKey = attribute.Key("db.statement")
+)
+
+// Option is a function that configures Wrap, WrapConnector and OpenDB.
+type Option func(*options)
+
+type options struct {
+	system        string
+	sanitize      func(query string) string
+	slowThreshold time.Duration
+	slowLogLevel  xlog.Level
+}
+
+// WithSystem sets the "db.system.name" and "db.system" attributes of the spans, e.g. "postgresql".
+// The default is "other_sql".
+func WithSystem(name string) Option {
+	return func(o *options) {
+		o.system = name
+	}
+}
+
+// WithSanitizer sets the function applied to the queries before they are put in the spans and the logs,
+// e.g. SanitizeQuery. By default, the queries are recorded as is.
+func WithSanitizer(fn func(query string) string) Option {
+	return func(o *options) {
+		o.sanitize = fn
+	}
+}
+
+// WithSlowThreshold logs "slow query" for the operations taking at least threshold.
+// The default is 0, slow queries are not logged.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithSlowLogLevel sets the level of the "slow query" entries. The default is WarnLevel.
+func WithSlowLogLevel(level xlog.Level) Option {
+	return func(o *options) {
+		o.slowLogLevel = level
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		system:       semconv.DBSystemNameOtherSQL.Value.AsString(),
+		slowLogLevel: xlog.WarnLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Wrap returns a driver tracing and logging the operations of d:
+//
+//   - every statement, query, prepare, begin, commit and rollback gets a client span started
+//     from the context of the call, with the "db.system.name", "db.operation.name" and
+//     "db.query.text" attributes, and "db.system" and "db.statement", their names before
+//     the semantic conventions v1.26,
+//   - the spans of statements get the rows affected, the spans of queries the rows returned,
+//     and end when the rows are closed,
+//   - errors are recorded on the spans with xlog.RecordSpanError,
+//   - slow operations are logged with the logger from the context of the call, see WithSlowThreshold.
+//
+// Example:
+//
+//	sql.Register("postgres-traced", xsql.Wrap(&pq.Driver{}, xsql.WithSystem("postgresql")))
+//	db, err := sql.Open("postgres-traced", dsn)
+func Wrap(d driver.Driver, opts ...Option) driver.Driver {
+	return &wrappedDriver{driver: d, opts: newOptions(opts)}
+}
+
+// WrapConnector returns a connector tracing and logging the operations of the connections of c, see Wrap.
+func WrapConnector(c driver.Connector, opts ...Option) driver.Connector {
+	d := &wrappedDriver{driver: c.Driver(), opts: newOptions(opts)}
+	return &connector{connector: c, driver: d}
+}
+
+// OpenDB opens a database tracing and logging the operations of the connections of c, see Wrap.
+//
+// Example:
+//
+//	connector, err := pq.NewConnector(dsn)
+//	db := xsql.OpenDB(connector, xsql.WithSystem("postgresql"), xsql.WithSlowThreshold(time.Second))
+func OpenDB(c driver.Connector, opts ...Option) *sql.DB {
+	return sql.OpenDB(WrapConnector(c, opts...))
+}
+
+// wrappedDriver is the driver returned by Wrap.
+type wrappedDriver struct {
+	driver driver.Driver
+	opts   *options
+}
+
+// Open opens a connection with the wrapped driver.
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(c, d.opts), nil
+}
+
+// OpenConnector returns a connector of the wrapped driver, used by sql.Open.
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{connector: c, driver: d}, nil
+	}
+	return &connector{connector: dsnConnector{name: name, driver: d.driver}, driver: d}, nil
+}
+
+// connector wraps the connections of a driver.Connector.
+type connector struct {
+	connector driver.Connector
+	driver    *wrappedDriver
+}
+
+// Connect opens a connection with the wrapped connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(conn, c.driver.opts), nil
+}
+
+// Driver returns the wrapped driver.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the wrapped connector if it supports it, called by sql.DB.Close.
+func (c *connector) Close() error {
+	if closer, ok := c.connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector is the connector of the drivers without driver.DriverContext.
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dbSpan is the span of a database operation.
+type dbSpan struct {
+	trace.Span
+	ctx       context.Context
+	start     time.Time
+	operation string
+	query     string
+	opts      *options
+}
+
+// startSpan starts the client span of operation, the first keyword of query if empty.
+func (o *options) startSpan(ctx context.Context, operation, query string) (context.Context, *dbSpan) {
+	return o.startSpanAt(ctx, operation, query, time.Now())
+}
+
+// startSpanAt is startSpan for an operation that started at the given time.
+func (o *options) startSpanAt(ctx context.Context, operation, query string, start time.Time) (context.Context, *dbSpan) {
+	if o.sanitize != nil && query != "" {
+		query = o.sanitize(query)
+	}
+	if operation == "" {
+		operation = queryOperation(query, o.system)
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNameKey.String(o.system),
+		legacySystemKey.String(o.system),
+		semconv.DBOperationName(operation),
+	}
+	if query != "" {
+		attrs = append(attrs, semconv.DBQueryText(query), legacyStatementKey.String(query))
+	}
+	ctx, span := xlog.TracerFromContext(ctx).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithTimestamp(start),
+	)
+	return ctx, &dbSpan{Span: span, ctx: ctx, start: start, operation: operation, query: query, opts: o}
+}
+
+// end records err, logs the operation if slow and ends the span.
+func (s *dbSpan) end(err error, attrs ...attribute.KeyValue) {
+	defer s.End()
+
+	s.SetAttributes(attrs...)
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		xlog.RecordSpanError(s.ctx, err)
+	}
+
+	duration := time.Since(s.start)
+	if s.opts.slowThreshold <= 0 || duration < s.opts.slowThreshold || s.opts.slowLogLevel > xlog.FatalLevel {
+		return
+	}
+	fields := []xfield.Field{
+		xfield.String("operation", s.operation),
+		xfield.String("query", s.query),
+		xfield.Duration("duration", duration),
+	}
+	if err != nil {
+		fields = append(fields, xfield.Error(err))
+	}
+	// the entry gets the IDs of the span, without recording err again
+	logCtx := trace.ContextWithSpanContext(s.ctx, s.SpanContext())
+	xlog.Log(logCtx, s.opts.slowLogLevel, "slow query", fields...)
+}
+
+// queryOperation returns the first keyword of query in upper case, e.g. "SELECT", or else fallback.
+func queryOperation(query, fallback string) string {
+	words := strings.Fields(query)
+	if len(words) == 0 || strings.IndexFunc(words[0], func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+		return fallback
+	}
+	return strings.ToUpper(words[0])
+}
@@ -0,0 +1,245 @@
+package xsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ruko1202/xlog"
+)
+
+var errFake = errors.New("fake error")
+
+// fakeDriver is an in-process driver: queries return its rows, statements containing "fail"
+// return errFake and the ones containing "slow" take 20ms. Its connections return driver.ErrSkip
+// for the statements containing "prepare", for database/sql to prepare them. Its connections support the context
+// interfaces, its statements and transactions only the legacy ones.
+type fakeDriver struct {
+	rows [][]driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+func (d *fakeDriver) run(query string) error {
+	if strings.Contains(query, "slow") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if strings.Contains(query, "fail") {
+		return errFake
+	}
+	return nil
+}
+
+type fakeConnector struct {
+	driver *fakeDriver
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open("")
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{driver: c.driver, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "prepare") {
+		return nil, driver.ErrSkip
+	}
+	if err := c.driver.run(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "prepare") {
+		return nil, driver.ErrSkip
+	}
+	if err := c.driver.run(query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: c.driver.rows}, nil
+}
+
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if err := s.driver.run(s.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if err := s.driver.run(s.query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: s.driver.rows}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "name"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func setupTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	spanRecorder := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+	return spanRecorder
+}
+
+func initTestLogger(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(xlog.ReplaceGlobalLogger(xlog.NewZapAdapter(zap.New(core))))
+	return logs
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestWrap(t *testing.T) {
+	spanRecorder := setupTestTracer(t)
+
+	connector, err := Wrap(&fakeDriver{}, WithSystem("sqlite")).(driver.DriverContext).OpenConnector("fake")
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	result, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES (?)", "bob")
+	require.NoError(t, err)
+	parent.End()
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, affected)
+
+	spans := spanRecorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "INSERT", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	attrs := spanAttributes(span)
+	assert.Equal(t, "sqlite", attrs["db.system.name"].AsString())
+	assert.Equal(t, "sqlite", attrs["db.system"].AsString())
+	assert.Equal(t, "INSERT", attrs["db.operation.name"].AsString())
+	assert.Equal(t, "INSERT INTO users (name) VALUES (?)", attrs["db.query.text"].AsString())
+	assert.Equal(t, "INSERT INTO users (name) VALUES (?)", attrs["db.statement"].AsString())
+	assert.Equal(t, int64(3), attrs["db.response.affected_rows"].AsInt64())
+}
+
+func TestOpenDB(t *testing.T) {
+	spanRecorder := setupTestTracer(t)
+
+	db := OpenDB(fakeConnector{driver: &fakeDriver{rows: [][]driver.Value{{int64(1), "bob"}, {int64(2), "alice"}}}})
+	defer db.Close()
+	require.NoError(t, db.PingContext(context.Background()))
+
+	rows, err := db.QueryContext(context.Background(), "select id, name from users")
+	require.NoError(t, err)
+	assert.Empty(t, spanRecorder.Ended(), "the span ends when the rows are closed")
+	var names []string
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		require.NoError(t, rows.Scan(&id, &name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []string{"bob", "alice"}, names)
+	spans := spanRecorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "SELECT", spans[0].Name())
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "other_sql", attrs["db.system.name"].AsString())
+	assert.Equal(t, int64(2), attrs["db.response.returned_rows"].AsInt64())
+}
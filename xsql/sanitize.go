@@ -0,0 +1,123 @@
+package xsql
+
+import "strings"
+
+// SanitizeQuery replaces the string and numeric literals of query with "?", keeping
+// the identifiers and the placeholders such as $1, :name or @p1, for WithSanitizer.
+// Strings can be single-quoted, where quotes are escaped by doubling them, double-quoted as in MySQL,
+// where a backslash also escapes the next byte, or dollar-quoted as in PostgreSQL ($$...$$ or $tag$...$tag$).
+// A backslash is an escape in single-quoted strings only with the E prefix (E'...'), as in PostgreSQL
+// with standard_conforming_strings on; use SanitizeMySQLQuery for the servers escaping it everywhere.
+// As double-quoted strings are replaced too, so are the identifiers quoted that way.
+//
+// Example:
+//
+//	xsql.SanitizeQuery("SELECT * FROM users WHERE id = 42 AND name = 'bob' AND org = $1")
+//	// SELECT * FROM users WHERE id = ? AND name = ? AND org = $1
+func SanitizeQuery(query string) string {
+	return sanitizeQuery(query, false)
+}
+
+// SanitizeMySQLQuery is like SanitizeQuery, but a backslash escapes the next byte in all the strings,
+// as in MySQL without the NO_BACKSLASH_ESCAPES mode.
+//
+// Example:
+//
+//	xsql.SanitizeMySQLQuery(`UPDATE notes SET text = 'it\'s secret' WHERE id = 1`)
+//	// UPDATE notes SET text = ? WHERE id = ?
+func SanitizeMySQLQuery(query string) string {
+	return sanitizeQuery(query, true)
+}
+
+func sanitizeQuery(query string, backslashEscapes bool) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipString(query, i, backslashEscapes || c == '"')
+			b.WriteByte('?')
+		case (c == 'E' || c == 'e') && i+1 < len(query) && query[i+1] == '\'' && (i == 0 || !isIdentByte(query[i-1])):
+			i = skipString(query, i+1, true)
+			b.WriteByte('?')
+		case c == '$' && dollarTag(query, i) != "":
+			i = skipDollarString(query, i)
+			b.WriteByte('?')
+		case isDigit(c):
+			for i < len(query) && (isIdentByte(query[i]) || query[i] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		case isIdentByte(c) || c == ':' || c == '@':
+			start := i
+			i++
+			for i < len(query) && isIdentByte(query[i]) {
+				i++
+			}
+			b.WriteString(query[start:i])
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipString returns the index following the string literal starting at i, quoted with the byte at i,
+// where doubled quotes, and backslashes with backslashEscapes, escape the next byte.
+func skipString(query string, i int, backslashEscapes bool) int {
+	quote := query[i]
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// dollarTag returns the opening tag of the dollar-quoted string starting at i, such as "$$" or "$tag$",
+// or "" if there is none, e.g. for the placeholder $1.
+func dollarTag(query string, i int) string {
+	for j := i + 1; j < len(query); j++ {
+		c := query[j]
+		switch {
+		case c == '$':
+			return query[i : j+1]
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80 || isDigit(c) && j > i+1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// skipDollarString returns the index following the dollar-quoted string starting at i.
+func skipDollarString(query string, i int) int {
+	tag := dollarTag(query, i)
+	end := strings.Index(query[i+len(tag):], tag)
+	if end < 0 {
+		return len(query)
+	}
+	return i + len(tag) + end + len(tag)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isIdentByte reports whether c can be part of an identifier or a placeholder;
+// the bytes of multi-byte characters are kept as is.
+func isIdentByte(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$' || c >= 0x80
+}
@@ -0,0 +1,79 @@
+package xsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "literals",
+			query: "SELECT * FROM users WHERE id = 42 AND name = 'bob' AND score > -1.5",
+			want:  "SELECT * FROM users WHERE id = ? AND name = ? AND score > -?",
+		},
+		{
+			name:  "escaped quotes",
+			query: "UPDATE notes SET text = 'it''s' WHERE id = 0x1F",
+			want:  "UPDATE notes SET text = ? WHERE id = ?",
+		},
+		{
+			name:  "placeholders and identifiers",
+			query: "INSERT INTO t1 (`col2`, c_3, a$b) VALUES ($1, :name, @p1, ?)",
+			want:  "INSERT INTO t1 (`col2`, c_3, a$b) VALUES ($1, :name, @p1, ?)",
+		},
+		{
+			name:  "backslashes in standard strings",
+			query: `SELECT * FROM f WHERE path = 'C:\' AND pw = 'hunter2'`,
+			want:  "SELECT * FROM f WHERE path = ? AND pw = ?",
+		},
+		{
+			name:  "backslash escapes in escape strings",
+			query: `UPDATE notes SET text = E'it\'s secret', note = e'\\', type = 'x' WHERE id = 1`,
+			want:  "UPDATE notes SET text = ?, note = ?, type = ? WHERE id = ?",
+		},
+		{
+			name:  "double-quoted strings",
+			query: `SELECT * FROM users WHERE name = "bob" OR name = "say ""hi"" \"x\""`,
+			want:  "SELECT * FROM users WHERE name = ? OR name = ?",
+		},
+		{
+			name:  "dollar-quoted strings",
+			query: "SELECT $$it's $1$$, $tag$a $$ b$tag$, $2 FROM t",
+			want:  "SELECT ?, ?, $2 FROM t",
+		},
+		{
+			name:  "unterminated dollar-quoted string",
+			query: "SELECT $q$abc",
+			want:  "SELECT ?",
+		},
+		{
+			name:  "unterminated string",
+			query: "SELECT 'abc",
+			want:  "SELECT ?",
+		},
+		{
+			name:  "unicode identifiers",
+			query: "SELECT имя FROM t WHERE x IN (1, 2)",
+			want:  "SELECT имя FROM t WHERE x IN (?, ?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeQuery(tt.query))
+		})
+	}
+}
+
+func TestSanitizeMySQLQuery(t *testing.T) {
+	assert.Equal(t,
+		"UPDATE notes SET text = ?, path = ? WHERE id = ? AND name = ?",
+		SanitizeMySQLQuery(`UPDATE notes SET text = 'it\'s secret', path = 'C:\\' WHERE id = 1 AND name = "a\"b"`),
+	)
+}
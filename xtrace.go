@@ -12,6 +12,7 @@ type xTraceCtxKey int
 
 const (
 	tracerCtxKey xTraceCtxKey = iota
+	propagatorCtxKey
 )
 
 var (
@@ -2,6 +2,7 @@ package xlog
 
 import (
 	"context"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -23,13 +24,90 @@ import (
 //	)
 //	defer span.End()
 func WithOperationSpan(ctx context.Context, operation string, fields ...xfield.Field) (context.Context, trace.Span) {
+	return startOperationSpan(ctx, operation, fields, nil)
+}
+
+// SpanOption is a function that configures the span created by WithOperationSpanOpts,
+// TraceOpts, TraceValueOpts and StartOperationOpts.
+type SpanOption func(*spanOptions)
+
+type spanOptions struct {
+	fields          []xfield.Field
+	start           []trace.SpanStartOption
+	completionLevel *Level
+}
+
+func newSpanOptions(options []SpanOption) *spanOptions {
+	opts := &spanOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return opts
+}
+
+// SpanFields adds the fields to the logger and as span attributes, like the fields of WithOperationSpan.
+func SpanFields(fields ...xfield.Field) SpanOption {
+	return func(o *spanOptions) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// SpanKind sets the kind of the span (server, client, producer, consumer or internal).
+func SpanKind(kind trace.SpanKind) SpanOption {
+	return SpanStartOptions(trace.WithSpanKind(kind))
+}
+
+// SpanLinks links the span to other spans, e.g. the producers of a batch of messages.
+func SpanLinks(links ...trace.Link) SpanOption {
+	return SpanStartOptions(trace.WithLinks(links...))
+}
+
+// SpanStartTime sets the start time of the span, e.g. when a request was received
+// before the span could be created.
+func SpanStartTime(t time.Time) SpanOption {
+	return SpanStartOptions(trace.WithTimestamp(t))
+}
+
+// NewRoot makes the span the root of a new trace, ignoring the span of the context.
+// Link it to the span of the context with SpanLinks to keep the relationship.
+func NewRoot() SpanOption {
+	return SpanStartOptions(trace.WithNewRoot())
+}
+
+// SpanStartOptions passes the options to tracer.Start as they are.
+func SpanStartOptions(options ...trace.SpanStartOption) SpanOption {
+	return func(o *spanOptions) {
+		o.start = append(o.start, options...)
+	}
+}
+
+// WithOperationSpanOpts creates a new span and a named logger for the operation like WithOperationSpan,
+// configured with options: the fields (SpanFields) and the options passed to tracer.Start
+// (SpanKind, SpanLinks, SpanStartTime, NewRoot and SpanStartOptions).
+//
+// Example:
+//
+//	ctx, span := xlog.WithOperationSpanOpts(ctx, "consume-orders",
+//	    xlog.SpanKind(trace.SpanKindConsumer),
+//	    xlog.SpanLinks(trace.LinkFromContext(producerCtx)),
+//	    xlog.SpanFields(xfield.String("topic", "orders")),
+//	)
+//	defer span.End()
+func WithOperationSpanOpts(ctx context.Context, operation string, options ...SpanOption) (context.Context, trace.Span) {
+	opts := newSpanOptions(options)
+	return startOperationSpan(ctx, operation, opts.fields, opts.start)
+}
+
+func startOperationSpan(
+	ctx context.Context, operation string, fields []xfield.Field, startOptions []trace.SpanStartOption,
+) (context.Context, trace.Span) {
 	logger := loggerFromContext(ctx).
 		Named(operation).
 		With(fields...)
 
 	// Trace/span metadata will be added directly in Debug/Info/etc
 	tracer := tracerFromContext(ctx)
-	ctx, span := tracer.Start(ctx, operation)
+	ctx, span := tracer.Start(ctx, operation, startOptions...)
 	if span.IsRecording() {
 		span.SetAttributes(fieldsToOtelAttributes(fields)...)
 	}
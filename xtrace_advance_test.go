@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -142,6 +143,62 @@ func TestWithOperationSpan(t *testing.T) {
 	})
 }
 
+func TestWithOperationSpanOpts(t *testing.T) {
+	t.Run("passes start options and fields", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		ctx, parent := WithOperationSpan(ctx, "parent")
+		link := trace.LinkFromContext(ctx, attribute.String("link", "producer"))
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		ctx, span := WithOperationSpanOpts(ctx, "consume",
+			SpanKind(trace.SpanKindConsumer),
+			SpanLinks(link),
+			SpanStartTime(start),
+			SpanFields(xfield.String("topic", "orders")),
+			SpanFields(xfield.Int("partition", 3)),
+		)
+		Info(ctx, "consumed")
+		span.End()
+		parent.End()
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, "parent.consume", entry.LoggerName)
+		assert.Equal(t, "orders", entry.ContextMap()["topic"])
+		assert.EqualValues(t, 3, entry.ContextMap()["partition"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "consume", spans[0].Name())
+		assert.Equal(t, trace.SpanKindConsumer, spans[0].SpanKind())
+		assert.Equal(t, start, spans[0].StartTime())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		require.Len(t, spans[0].Links(), 1)
+		assert.Equal(t, link.SpanContext, spans[0].Links()[0].SpanContext)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("topic", "orders"),
+			attribute.Int64("partition", 3),
+		}, spans[0].Attributes())
+	})
+
+	t.Run("new root starts a new trace", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		ctx, parent := WithOperationSpan(context.Background(), "parent")
+		_, span := WithOperationSpanOpts(ctx, "root", NewRoot(), SpanStartOptions(trace.WithSpanKind(trace.SpanKindServer)))
+		span.End()
+		parent.End()
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.False(t, spans[0].Parent().IsValid())
+		assert.NotEqual(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+		assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	})
+}
+
 func TestSpanFromContext(t *testing.T) {
 	t.Run("returns span from context", func(t *testing.T) {
 		setupTestTracer(t)
@@ -15,25 +15,45 @@ func fieldsToOtelAttributes(fields []xfield.Field) []attribute.KeyValue {
 	}
 
 	// Pre-count supported fields to avoid reallocation
+	count := countOtelAttributes(fields)
+	if count == 0 {
+		return nil
+	}
+
+	return appendOtelAttributes(make([]attribute.KeyValue, 0, count), "", fields)
+}
+
+// countOtelAttributes counts the attributes produced by the fields, including the nested fields of groups.
+func countOtelAttributes(fields []xfield.Field) int {
 	count := 0
 	for i := range fields {
-		if isConvertible(fields[i].Type) {
+		switch {
+		case fields[i].Type == xfield.GroupType:
+			nested, _ := fields[i].Interface.([]xfield.Field)
+			count += countOtelAttributes(nested)
+		case isConvertible(fields[i].Type):
 			count++
 		}
 	}
+	return count
+}
 
-	if count == 0 {
-		return nil
-	}
-
-	otelAttrs := make([]attribute.KeyValue, 0, count)
+// appendOtelAttributes appends the attributes of the fields, flattening groups into dotted keys.
+func appendOtelAttributes(attrs []attribute.KeyValue, prefix string, fields []xfield.Field) []attribute.KeyValue {
 	for i := range fields {
-		if isConvertible(fields[i].Type) {
-			otelAttrs = append(otelAttrs, fieldToOtelAttribute(fields[i]))
+		f := fields[i]
+		if prefix != "" {
+			f.Key = prefix + "." + f.Key
+		}
+		switch {
+		case f.Type == xfield.GroupType:
+			nested, _ := f.Interface.([]xfield.Field)
+			attrs = appendOtelAttributes(attrs, f.Key, nested)
+		case isConvertible(f.Type):
+			attrs = append(attrs, fieldToOtelAttribute(f))
 		}
 	}
-
-	return otelAttrs
+	return attrs
 }
 
 // isFieldTypeConvertibleToAttribute checks if a field type can be converted to an attribute.
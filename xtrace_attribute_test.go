@@ -96,6 +96,23 @@ func TestConvertFieldsToAttributes(t *testing.T) {
 		assert.Equal(t, attribute.Int64("int", 42), attrs[1])
 	})
 
+	t.Run("flattens groups into dotted keys", func(t *testing.T) {
+		fields := []xfield.Field{
+			xfield.Group("http",
+				xfield.String("method", "GET"),
+				xfield.Any("ignored", struct{}{}),
+				xfield.Group("response", xfield.Int("status", 200)),
+			),
+			xfield.Group("empty"),
+		}
+
+		attrs := fieldsToOtelAttributes(fields)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("http.method", "GET"),
+			attribute.Int64("http.response.status", 200),
+		}, attrs)
+	})
+
 	t.Run("handles mixed field types", func(t *testing.T) {
 		fields := []xfield.Field{
 			xfield.String("name", "test"),
@@ -0,0 +1,238 @@
+package xlog
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+// Statuses of the completion entry of Trace, TraceValue and StartOperation.
+const (
+	traceStatusOK    = "ok"
+	traceStatusError = "error"
+	traceStatusPanic = "panic"
+)
+
+// _traceLogLevel is the level of the completion entry of Trace, TraceValue and StartOperation.
+// The default is above FatalLevel, so no entry is logged.
+var _traceLogLevel atomic.Int32
+
+func init() {
+	_traceLogLevel.Store(int32(FatalLevel + 1))
+}
+
+// ReplaceTraceLogLevel makes Trace, TraceValue and StartOperation log a completion entry at the level,
+// "operation finished" with the "duration", the "status" ("ok", "error" or "panic") and the error.
+// The entry is written by the logger of the operation, so it has its name and fields.
+// Completion entries are disabled by default; levels above FatalLevel disable them.
+// CompletionLogLevel overrides the level for a call.
+// Returns a function restoring the previous level.
+// This function is thread-safe and can be called concurrently.
+//
+// Example:
+//
+//	restore := xlog.ReplaceTraceLogLevel(xlog.DebugLevel)
+//	defer restore()
+func ReplaceTraceLogLevel(level Level) func() {
+	prev := Level(_traceLogLevel.Swap(int32(level)))
+	return func() { ReplaceTraceLogLevel(prev) }
+}
+
+func traceLogLevel() Level {
+	return Level(_traceLogLevel.Load())
+}
+
+// CompletionLogLevel sets the level of the completion entry of TraceOpts, TraceValueOpts and
+// StartOperationOpts for this call, instead of the level set by ReplaceTraceLogLevel.
+// Levels above FatalLevel disable the entry. WithOperationSpanOpts ignores it.
+func CompletionLogLevel(level Level) SpanOption {
+	return func(o *spanOptions) {
+		o.completionLevel = &level
+	}
+}
+
+// completionLogLevel returns the level set by CompletionLogLevel, or else by ReplaceTraceLogLevel.
+func (o *spanOptions) completionLogLevel() Level {
+	if o.completionLevel != nil {
+		return *o.completionLevel
+	}
+	return traceLogLevel()
+}
+
+// Trace runs fn in a span created like WithOperationSpan and ends the span when fn returns.
+// A non-nil error is recorded with RecordSpanError, otherwise the span status is set to Ok,
+// and the span gets a "duration" attribute.
+// A panic is recorded on the span with its stack trace, then fn panics again.
+// See ReplaceTraceLogLevel to log a completion entry, and TraceOpts to configure the span.
+//
+// Example:
+//
+//	err := xlog.Trace(ctx, "charge-card", func(ctx context.Context) error {
+//	    return payments.Charge(ctx, card, amount)
+//	}, xfield.String("user_id", userID))
+func Trace(ctx context.Context, operation string, fn func(ctx context.Context) error, fields ...xfield.Field) error {
+	return TraceOpts(ctx, operation, fn, SpanFields(fields...))
+}
+
+// TraceOpts is Trace with the options of WithOperationSpanOpts, and CompletionLogLevel.
+//
+// Example:
+//
+//	err := xlog.TraceOpts(ctx, "charge-card", func(ctx context.Context) error {
+//	    return payments.Charge(ctx, card, amount)
+//	}, xlog.SpanKind(trace.SpanKindClient), xlog.CompletionLogLevel(xlog.InfoLevel))
+func TraceOpts(ctx context.Context, operation string, fn func(ctx context.Context) error, options ...SpanOption) error {
+	_, err := TraceValueOpts(ctx, operation, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, options...)
+	return err
+}
+
+// TraceValue is Trace for functions returning a value.
+//
+// Example:
+//
+//	user, err := xlog.TraceValue(ctx, "load-user", func(ctx context.Context) (*User, error) {
+//	    return repo.User(ctx, id)
+//	}, xfield.String("user_id", id))
+func TraceValue[T any](
+	ctx context.Context, operation string, fn func(ctx context.Context) (T, error), fields ...xfield.Field,
+) (T, error) {
+	return TraceValueOpts(ctx, operation, fn, SpanFields(fields...))
+}
+
+// TraceValueOpts is TraceValue with the options of WithOperationSpanOpts, and CompletionLogLevel.
+func TraceValueOpts[T any](
+	ctx context.Context, operation string, fn func(ctx context.Context) (T, error), options ...SpanOption,
+) (result T, err error) {
+	opts := newSpanOptions(options)
+	ctx, span := startOperationSpan(ctx, operation, opts.fields, opts.start)
+	level := opts.completionLogLevel()
+	start := time.Now()
+
+	panicked := true
+	defer func() {
+		if !panicked {
+			finishTrace(ctx, span, start, err, nil, level)
+			return
+		}
+		// recover returns nil when fn calls runtime.Goexit, which must go on
+		recovered := recover()
+		finishTrace(ctx, span, start, nil, recovered, level)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+
+	result, err = fn(ctx)
+	panicked = false
+	return result, err
+}
+
+// EndFunc ends the span started by StartOperation with the outcome of the operation.
+// It takes a pointer to the error so it can be deferred with a named error return,
+// see Err to pass the error itself. Only the first call has an effect.
+// When deferred directly, it records a panic of the operation like Trace and panics again.
+type EndFunc func(errp *error)
+
+// Err ends the span with the error, for callers without a named error return.
+// Deferring Err doesn't record panics, as only EndFunc itself can recover them.
+func (end EndFunc) Err(err error) {
+	end(&err)
+}
+
+// StartOperation starts a span and a named logger for the operation like WithOperationSpan,
+// and returns a function ending the span with the outcome of the operation, like Trace:
+// a non-nil error is recorded with RecordSpanError, otherwise the span status is set to Ok.
+// The span gets a "duration" attribute, and see ReplaceTraceLogLevel to log a completion entry.
+// A panic is recorded on the span with its stack trace when end is deferred directly, then
+// the operation panics again.
+//
+// Example:
+//
+//	func (s *Service) Charge(ctx context.Context, card Card) (err error) {
+//	    ctx, end := xlog.StartOperation(ctx, "charge-card", xfield.String("card_id", card.ID))
+//	    defer end(&err)
+//
+//	    return s.payments.Charge(ctx, card)
+//	}
+func StartOperation(ctx context.Context, operation string, fields ...xfield.Field) (context.Context, EndFunc) {
+	return StartOperationOpts(ctx, operation, SpanFields(fields...))
+}
+
+// StartOperationOpts is StartOperation with the options of WithOperationSpanOpts, and CompletionLogLevel.
+//
+// Example:
+//
+//	ctx, end := xlog.StartOperationOpts(ctx, "charge-card", xlog.CompletionLogLevel(xlog.DebugLevel))
+//	defer end(&err)
+func StartOperationOpts(ctx context.Context, operation string, options ...SpanOption) (context.Context, EndFunc) {
+	opts := newSpanOptions(options)
+	ctx, span := startOperationSpan(ctx, operation, opts.fields, opts.start)
+	level := opts.completionLogLevel()
+	start := time.Now()
+
+	var ended atomic.Bool
+	return ctx, func(errp *error) {
+		if !ended.CompareAndSwap(false, true) {
+			return
+		}
+		// recover returns nil unless end is deferred directly and the operation panics
+		recovered := recover()
+		var err error
+		if errp != nil {
+			err = *errp
+		}
+		finishTrace(ctx, span, start, err, recovered, level)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}
+}
+
+// finishTrace records the outcome of a traced function and its duration on its span,
+// logs the completion entry at the level unless it is above FatalLevel, and ends the span.
+// A recovered panic is turned into a *PanicError, finishTrace must then be called directly
+// by the deferred function. Returns the error of the outcome.
+func finishTrace(ctx context.Context, span trace.Span, start time.Time, err error, recovered any, level Level) error {
+	defer span.End()
+
+	duration := xfield.Duration("duration", time.Since(start))
+	if span.IsRecording() {
+		span.SetAttributes(fieldToOtelAttribute(duration))
+	}
+
+	status := traceStatusOK
+	switch {
+	case recovered != nil:
+		status = traceStatusPanic
+		err = newPanicError(recovered, 1) // skip the deferred function
+		RecordSpanError(ctx, err, errorEventOptions(err, "")...)
+	case err != nil:
+		status = traceStatusError
+		RecordSpanError(ctx, err, errorEventOptions(err, "")...)
+	default:
+		if span.IsRecording() {
+			span.SetStatus(codes.Ok, "")
+		}
+	}
+
+	if level > FatalLevel {
+		return err
+	}
+	fields := []xfield.Field{
+		duration,
+		xfield.String("status", status),
+	}
+	if err != nil {
+		fields = append(fields, xfield.Error(err))
+	}
+	// the span already has the error, so the entry doesn't go through markSpanError
+	logWithContext(ctx, loggerFromContext(ctx), level, "operation finished", withMetadataFields(ctx, fields))
+	return err
+}
@@ -0,0 +1,263 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func TestTrace(t *testing.T) {
+	t.Run("sets ok status and ends the span", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		err := Trace(ctx, "charge", func(ctx context.Context) error {
+			Info(ctx, "charging")
+			return nil
+		}, xfield.String("user_id", "42"))
+		require.NoError(t, err)
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "charge", logs.All()[0].LoggerName)
+		assert.Equal(t, "42", logs.All()[0].ContextMap()["user_id"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "charge", spans[0].Name())
+		assert.Equal(t, codes.Ok, spans[0].Status().Code)
+		attrs := spans[0].Attributes()
+		require.Len(t, attrs, 2)
+		assert.Equal(t, attribute.String("user_id", "42"), attrs[0])
+		assert.Equal(t, attribute.Key("duration"), attrs[1].Key)
+	})
+
+	t.Run("records the error", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		boom := errors.New("boom")
+
+		err := Trace(context.Background(), "charge", func(context.Context) error { return boom })
+		assert.Same(t, boom, err)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "boom", spans[0].Status().Description)
+		require.Len(t, spans[0].Events(), 1)
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	})
+
+	t.Run("records the fields and the stack of the error", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+
+		err := Trace(context.Background(), "charge", func(context.Context) error {
+			return WrapErr(errors.New("declined"), xfield.String("card_id", "c1"))
+		})
+		require.EqualError(t, err, "declined")
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		require.Len(t, spans[0].Events(), 1)
+		event := attribute.NewSet(spans[0].Events()[0].Attributes...)
+		cardID, _ := event.Value("card_id")
+		assert.Equal(t, "c1", cardID.AsString())
+		stack, _ := event.Value("exception.stacktrace")
+		assert.Contains(t, stack.AsString(), "xlog.TestTrace")
+	})
+
+	t.Run("records the panic and panics again", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = Trace(context.Background(), "charge", func(context.Context) error { panic("boom") })
+		})
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "panic: boom", spans[0].Status().Description)
+		require.Len(t, spans[0].Events(), 1)
+		event := attribute.NewSet(spans[0].Events()[0].Attributes...)
+		stack, ok := event.Value("exception.stacktrace")
+		require.True(t, ok)
+		assert.Contains(t, stack.AsString(), "xlog.TestTrace")
+	})
+}
+
+func TestTraceOpts(t *testing.T) {
+	t.Run("configures the span and the completion entry", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		err := TraceOpts(ctx, "charge", func(context.Context) error { return nil },
+			SpanKind(trace.SpanKindClient),
+			SpanFields(xfield.String("card_id", "c1")),
+			CompletionLogLevel(InfoLevel),
+		)
+		require.NoError(t, err)
+		value, err := TraceValueOpts(ctx, "load", func(context.Context) (int, error) { return 42, nil },
+			CompletionLogLevel(DebugLevel),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+		assert.Equal(t, "operation finished", entries[0].Message)
+		assert.Equal(t, "c1", entries[0].ContextMap()["card_id"])
+		assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	})
+
+	t.Run("overrides the global level", func(t *testing.T) {
+		setupTestTracer(t)
+		t.Cleanup(ReplaceTraceLogLevel(InfoLevel))
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		require.NoError(t, TraceOpts(ctx, "quiet", func(context.Context) error { return nil }, CompletionLogLevel(FatalLevel+1)))
+		_, end := StartOperationOpts(ctx, "verbose", CompletionLogLevel(WarnLevel))
+		end(nil)
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "verbose", logs.All()[0].LoggerName)
+		assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	})
+}
+
+func TestTraceValue(t *testing.T) {
+	setupTestTracer(t)
+
+	value, err := TraceValue(context.Background(), "load", func(context.Context) (int, error) { return 42, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	value, err = TraceValue(context.Background(), "load", func(context.Context) (int, error) { return 0, errors.New("boom") })
+	require.EqualError(t, err, "boom")
+	assert.Zero(t, value)
+}
+
+func TestStartOperation(t *testing.T) {
+	charge := func(ctx context.Context, fail bool) (err error) {
+		ctx, end := StartOperation(ctx, "charge", xfield.String("card_id", "c1"))
+		defer end(&err)
+
+		Info(ctx, "charging")
+		if fail {
+			return errors.New("declined")
+		}
+		return nil
+	}
+
+	t.Run("ends the span with the named error return", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		require.NoError(t, charge(ctx, false))
+		require.EqualError(t, charge(ctx, true), "declined")
+
+		require.Equal(t, 2, logs.Len())
+		assert.Equal(t, "charge", logs.All()[0].LoggerName)
+		assert.Equal(t, "c1", logs.All()[0].ContextMap()["card_id"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, codes.Ok, spans[0].Status().Code)
+		assert.Empty(t, spans[0].Events())
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, "declined", spans[1].Status().Description)
+		require.Len(t, spans[1].Events(), 1)
+		for _, span := range spans {
+			attrs := attribute.NewSet(span.Attributes()...)
+			assert.True(t, attrs.HasValue("duration"))
+		}
+	})
+
+	t.Run("records the panic and panics again", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		t.Cleanup(ReplaceTraceLogLevel(InfoLevel))
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			_, end := StartOperation(ctx, "charge")
+			var err error
+			defer end(&err)
+			panic("boom")
+		})
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "panic: boom", spans[0].Status().Description)
+		require.Len(t, spans[0].Events(), 1)
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "panic", logs.All()[0].ContextMap()["status"])
+		assert.Equal(t, "panic: boom", logs.All()[0].ContextMap()["error"])
+	})
+
+	t.Run("only the first call has an effect", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+
+		_, end := StartOperation(context.Background(), "op")
+		end.Err(errors.New("first"))
+		end.Err(errors.New("second"))
+		end(nil)
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "first", spans[0].Status().Description)
+		assert.Len(t, spans[0].Events(), 1)
+	})
+}
+
+func TestReplaceTraceLogLevel(t *testing.T) {
+	t.Run("logs a completion entry", func(t *testing.T) {
+		spanRecorder := setupTestTracer(t)
+		t.Cleanup(ReplaceTraceLogLevel(WarnLevel))
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		require.NoError(t, Trace(ctx, "ok", func(context.Context) error { return nil }))
+		require.Error(t, Trace(ctx, "failed", func(context.Context) error { return errors.New("boom") }))
+
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		for i, status := range []string{"ok", "error"} {
+			assert.Equal(t, zapcore.WarnLevel, entries[i].Level)
+			assert.Equal(t, "operation finished", entries[i].Message)
+			assert.Equal(t, status, entries[i].ContextMap()["status"])
+			assert.Contains(t, entries[i].ContextMap(), "duration")
+			assert.Contains(t, entries[i].ContextMap(), "trace_id")
+		}
+		assert.Equal(t, "failed", entries[1].LoggerName)
+		assert.Equal(t, "boom", entries[1].ContextMap()["error"])
+
+		spans := spanRecorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Len(t, spans[1].Events(), 1, "the error is recorded once")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		logger, logs := initTestLogger(t)
+		ctx := ContextWithLogger(context.Background(), logger)
+
+		require.NoError(t, Trace(ctx, "ok", func(context.Context) error { return nil }))
+		assert.Zero(t, logs.Len())
+	})
+}
@@ -0,0 +1,130 @@
+package xlog
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MapCarrier is a propagation.TextMapCarrier for message headers stored as map[string]string.
+type MapCarrier = propagation.MapCarrier
+
+// PairsCarrier is a propagation.TextMapCarrier for message headers stored as key-value pairs,
+// like Kafka or NATS headers. Set replaces the value of an existing key or appends a pair.
+//
+// Example:
+//
+//	headers := xlog.PairsCarrier(msg.Headers)
+//	xlog.Inject(ctx, &headers)
+//	msg.Headers = headers
+type PairsCarrier [][2]string
+
+// Get returns the value of the first pair with the key, or "".
+func (c *PairsCarrier) Get(key string) string {
+	for _, pair := range *c {
+		if pair[0] == key {
+			return pair[1]
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of the first pair with the key, or appends a pair.
+func (c *PairsCarrier) Set(key, value string) {
+	for i, pair := range *c {
+		if pair[0] == key {
+			(*c)[i][1] = value
+			return
+		}
+	}
+	*c = append(*c, [2]string{key, value})
+}
+
+// Keys returns the keys of the pairs.
+func (c *PairsCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c))
+	for _, pair := range *c {
+		keys = append(keys, pair[0])
+	}
+	return keys
+}
+
+// ContextWithPropagator returns a new context with the provided propagator attached.
+// Inject, Extract and the helpers built on them use it instead of the global propagator.
+func ContextWithPropagator(ctx context.Context, propagator propagation.TextMapPropagator) context.Context {
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return context.WithValue(ctx, propagatorCtxKey, propagator)
+}
+
+// PropagatorFromContext extracts a propagator from the context.
+// If no propagator is found, returns the global propagator from otel.GetTextMapPropagator(),
+// which propagates nothing until otel.SetTextMapPropagator is called.
+//
+// Example:
+//
+//	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+//	    propagation.TraceContext{}, propagation.Baggage{},
+//	))
+func PropagatorFromContext(ctx context.Context) propagation.TextMapPropagator {
+	if propagator, ok := ctx.Value(propagatorCtxKey).(propagation.TextMapPropagator); ok {
+		return propagator
+	}
+	return otel.GetTextMapPropagator()
+}
+
+// Inject writes the trace context (and the baggage, depending on the propagator) of ctx to the carrier.
+//
+// Example:
+//
+//	headers := xlog.MapCarrier{}
+//	xlog.Inject(ctx, headers)
+//	producer.Publish(ctx, topic, payload, headers)
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	PropagatorFromContext(ctx).Inject(ctx, carrier)
+}
+
+// Extract returns a copy of ctx with the remote trace context read from the carrier.
+// The remote span becomes the parent of the spans started from the returned context.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return PropagatorFromContext(ctx).Extract(ctx, carrier)
+}
+
+// InjectHeaders writes the trace context of ctx to the headers of an outgoing HTTP request.
+//
+// Example:
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	xlog.InjectHeaders(ctx, req.Header)
+func InjectHeaders(ctx context.Context, header http.Header) {
+	Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractHeaders returns a copy of ctx with the remote trace context read from the headers
+// of an incoming HTTP request.
+func ExtractHeaders(ctx context.Context, header http.Header) context.Context {
+	return Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// StartRemoteOperation extracts the remote trace context from the carrier and starts a span
+// and a named logger for the operation like WithOperationSpanOpts, as a child of the remote span.
+// The span kind is server unless set with SpanKind, e.g. trace.SpanKindConsumer for messages.
+//
+// Example:
+//
+//	headers := xlog.PairsCarrier(msg.Headers)
+//	ctx, span := xlog.StartRemoteOperation(ctx, &headers, "process-order",
+//	    xlog.SpanKind(trace.SpanKindConsumer),
+//	    xlog.SpanFields(xfield.String("topic", msg.Topic)),
+//	)
+//	defer span.End()
+func StartRemoteOperation(
+	ctx context.Context, carrier propagation.TextMapCarrier, operation string, options ...SpanOption,
+) (context.Context, trace.Span) {
+	options = append([]SpanOption{SpanKind(trace.SpanKindServer)}, options...)
+	return WithOperationSpanOpts(Extract(ctx, carrier), operation, options...)
+}
@@ -0,0 +1,108 @@
+package xlog
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ruko1202/xlog/xfield"
+)
+
+func TestPairsCarrier(t *testing.T) {
+	carrier := PairsCarrier{{"content-type", "json"}}
+	carrier.Set("traceparent", "a")
+	carrier.Set("traceparent", "b")
+
+	assert.Equal(t, PairsCarrier{{"content-type", "json"}, {"traceparent", "b"}}, carrier)
+	assert.Equal(t, "b", carrier.Get("traceparent"))
+	assert.Empty(t, carrier.Get("missing"))
+	assert.Equal(t, []string{"content-type", "traceparent"}, carrier.Keys())
+}
+
+func TestPropagatorFromContext(t *testing.T) {
+	assert.Equal(t, otel.GetTextMapPropagator(), PropagatorFromContext(context.Background()))
+
+	propagator := propagation.TraceContext{}
+	ctx := ContextWithPropagator(context.Background(), propagator)
+	assert.Equal(t, propagator, PropagatorFromContext(ctx))
+	assert.Equal(t, otel.GetTextMapPropagator(), PropagatorFromContext(ContextWithPropagator(ctx, nil)))
+}
+
+func TestInjectExtract(t *testing.T) {
+	setupTestTracer(t)
+	ctx := ContextWithPropagator(context.Background(), propagation.TraceContext{})
+	ctx, span := WithOperationSpan(ctx, "client")
+	defer span.End()
+
+	t.Run("http headers", func(t *testing.T) {
+		header := http.Header{}
+		InjectHeaders(ctx, header)
+		require.NotEmpty(t, header.Get("Traceparent"))
+
+		remote := trace.SpanContextFromContext(ExtractHeaders(ContextWithPropagator(context.Background(), propagation.TraceContext{}), header))
+		assert.True(t, remote.IsRemote())
+		assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+		assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+	})
+
+	t.Run("map carrier", func(t *testing.T) {
+		carrier := MapCarrier{}
+		Inject(ctx, carrier)
+		require.Contains(t, carrier, "traceparent")
+
+		remote := trace.SpanContextFromContext(Extract(ctx, carrier))
+		assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+	})
+
+	t.Run("global propagator", func(t *testing.T) {
+		prev := otel.GetTextMapPropagator()
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+		_, span := WithOperationSpan(context.Background(), "client")
+		defer span.End()
+		header := http.Header{}
+		InjectHeaders(trace.ContextWithSpan(context.Background(), span), header)
+		assert.NotEmpty(t, header.Get("Traceparent"))
+	})
+}
+
+func TestStartRemoteOperation(t *testing.T) {
+	spanRecorder := setupTestTracer(t)
+	logger, logs := initTestLogger(t)
+	propagator := propagation.TraceContext{}
+
+	producerCtx, producer := WithOperationSpan(context.Background(), "publish")
+	headers := PairsCarrier{}
+	propagator.Inject(producerCtx, &headers)
+	producer.End()
+
+	ctx := ContextWithPropagator(ContextWithLogger(context.Background(), logger), propagator)
+	ctx, span := StartRemoteOperation(ctx, &headers, "process-order",
+		SpanKind(trace.SpanKindConsumer),
+		SpanFields(xfield.String("topic", "orders")),
+	)
+	Info(ctx, "processing")
+	span.End()
+
+	_, server := StartRemoteOperation(ctx, MapCarrier{}, "handle")
+	server.End()
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "process-order", logs.All()[0].LoggerName)
+	assert.Equal(t, "orders", logs.All()[0].ContextMap()["topic"])
+
+	spans := spanRecorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, trace.SpanKindConsumer, spans[1].SpanKind())
+	assert.Equal(t, producer.SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, producer.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.True(t, spans[1].Parent().IsRemote())
+	assert.Equal(t, trace.SpanKindServer, spans[2].SpanKind())
+}